@@ -0,0 +1,123 @@
+package ini
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/kenshaw/ini/parser"
+)
+
+// Encoding is the text encoding of ini data.
+type Encoding int
+
+// Encoding values.
+const (
+	// UTF8 is UTF-8 without a byte order mark. This is the default.
+	UTF8 Encoding = iota
+
+	// UTF8BOM is UTF-8 with a byte order mark.
+	UTF8BOM
+
+	// UTF16LE is little-endian UTF-16 with a byte order mark, as written by
+	// many Windows tools.
+	UTF16LE
+
+	// UTF16BE is big-endian UTF-16 with a byte order mark.
+	UTF16BE
+)
+
+// String satisfies the fmt.Stringer interface.
+func (enc Encoding) String() string {
+	switch enc {
+	case UTF8:
+		return "utf-8"
+	case UTF8BOM:
+		return "utf-8 (bom)"
+	case UTF16LE:
+		return "utf-16le"
+	case UTF16BE:
+		return "utf-16be"
+	}
+	return "unknown"
+}
+
+// byte order marks.
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// bom returns the byte order mark and byte order for the encoding.
+func (enc Encoding) bom() ([]byte, binary.ByteOrder) {
+	switch enc {
+	case UTF8BOM:
+		return bomUTF8, nil
+	case UTF16LE:
+		return bomUTF16LE, binary.LittleEndian
+	case UTF16BE:
+		return bomUTF16BE, binary.BigEndian
+	}
+	return nil, nil
+}
+
+// decode detects the encoding of buf by its byte order mark, returning the
+// data transcoded to UTF-8 without the byte order mark.
+//
+// Returns parser.ErrInvalidEncoding, along with the data decoded so far, when
+// UTF-16 data has an odd length.
+func decode(buf []byte) ([]byte, Encoding, error) {
+	for _, enc := range []Encoding{UTF8BOM, UTF16LE, UTF16BE} {
+		bom, order := enc.bom()
+		if !bytes.HasPrefix(buf, bom) {
+			continue
+		}
+		buf = buf[len(bom):]
+		if order == nil {
+			return buf, enc, nil
+		}
+
+		u := make([]uint16, len(buf)/2)
+		for i := range u {
+			u[i] = order.Uint16(buf[2*i:])
+		}
+		b := make([]byte, 0, len(buf))
+		for _, r := range utf16.Decode(u) {
+			b = utf8.AppendRune(b, r)
+		}
+		if len(buf)%2 != 0 {
+			return b, enc, parser.ErrInvalidEncoding
+		}
+		return b, enc, nil
+	}
+	return buf, UTF8, nil
+}
+
+// encode encodes the UTF-8 data in buf using the encoding, adding the byte
+// order mark.
+func (enc Encoding) encode(buf []byte) []byte {
+	bom, order := enc.bom()
+	if order == nil {
+		return append(append([]byte(nil), bom...), buf...)
+	}
+
+	b := append(make([]byte, 0, len(bom)+2*len(buf)), bom...)
+	var p [2]byte
+	for _, u := range utf16.Encode([]rune(string(buf))) {
+		order.PutUint16(p[:], u)
+		b = append(b, p[:]...)
+	}
+	return b
+}
+
+// endPosition returns the position of the end of buf.
+func endPosition(buf []byte) parser.Position {
+	start := bytes.LastIndexByte(buf, '\n') + 1
+	return parser.Position{
+		Line:   bytes.Count(buf, []byte{'\n'}) + 1,
+		Col:    utf8.RuneCount(buf[start:]) + 1,
+		Offset: len(buf),
+	}
+}
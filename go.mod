@@ -1,3 +1,3 @@
 module github.com/kenshaw/ini
 
-go 1.15
+go 1.23
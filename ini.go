@@ -4,10 +4,10 @@
 package ini
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"strings"
 
@@ -25,25 +25,111 @@ func (err Error) Error() string {
 // Error values.
 const (
 	ErrNoFilenameSupplied Error = "no filename supplied"
+	ErrNotStructPointer   Error = "not a non-nil pointer to a struct"
+	ErrNotStruct          Error = "not a struct"
+	ErrUnsupportedType    Error = "unsupported type"
+	ErrUnknownKey         Error = "unknown key"
 )
 
 // ParseError is a ini parse error.
 type ParseError struct {
-	name string
-	err  error
+	Filename string // filename (or name) of the parsed data
+	Line     int    // line number, starting at 1
+	Column   int    // column number (in characters), starting at 1
+	Offset   int    // byte offset, starting at 0
+	Source   string // source line where the error occurred
+	Err      error  // underlying error
+}
+
+// newParseError creates a ParseError for the error err that occurred at pos
+// in buf.
+func newParseError(name string, buf []byte, pos parser.Position, err error) *ParseError {
+	// determine source line
+	start, end := pos.Offset, pos.Offset
+	if start > len(buf) {
+		start, end = len(buf), len(buf)
+	}
+	for start > 0 && buf[start-1] != '\n' {
+		start--
+	}
+	for end < len(buf) && buf[end] != '\n' {
+		end++
+	}
+
+	return &ParseError{
+		Filename: name,
+		Line:     pos.Line,
+		Column:   pos.Col,
+		Offset:   pos.Offset,
+		Source:   strings.TrimSuffix(string(buf[start:end]), "\r"),
+		Err:      err,
+	}
 }
 
 // Error satisfies the error interface.
 func (err *ParseError) Error() string {
-	return fmt.Sprintf("unable to parse %s: %v", err.name, err.err)
+	return fmt.Sprintf("unable to parse %s: line %d:%d: %v", err.Filename, err.Line, err.Column, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// Caret returns a marker line that points to the error's column when
+// displayed below Source.
+//
+// Tabs in Source preceding the column are preserved so that the marker lines
+// up when displayed.
+func (err *ParseError) Caret() string {
+	var buf strings.Builder
+	col := 1
+	for _, r := range err.Source {
+		if col >= err.Column {
+			break
+		}
+		if r == '\t' {
+			buf.WriteRune('\t')
+		} else {
+			buf.WriteRune(' ')
+		}
+		col++
+	}
+	buf.WriteRune('^')
+	return buf.String()
+}
+
+// ParseErrors is a list of parse errors, returned when parsing with the
+// parser.Lenient option.
+type ParseErrors []*ParseError
+
+// Error satisfies the error interface.
+func (errs ParseErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "no errors"
+	case 1:
+		return errs[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", errs[0], len(errs)-1)
+}
+
+// Unwrap returns the errors in the list.
+func (errs ParseErrors) Unwrap() []error {
+	v := make([]error, len(errs))
+	for i, err := range errs {
+		v[i] = err
+	}
+	return v
 }
 
 // File wraps parser.File with information about an ini file.
 //
 // File can be written to disk by calling File.Save.
 type File struct {
-	*parser.File        // ini file
-	Filename     string // filename to read/write from/to
+	*parser.File          // ini file
+	Filename     string   // filename to read/write from/to
+	Encoding     Encoding // text encoding used to read/write the file data
 }
 
 // NewFile creates a new File.
@@ -57,7 +143,7 @@ func NewFile() *File {
 // Save writes the ini file data to File.Filename.
 //
 // Returns error if File.Filename name was not set, or if an error was
-// encountered during write. Simple wrapper around parser.File.Write.
+// encountered during write. Simple wrapper around File.Write.
 func (f *File) Save() error {
 	if f.Filename == "" {
 		return ErrNoFilenameSupplied
@@ -65,52 +151,108 @@ func (f *File) Save() error {
 	return f.Write(f.Filename)
 }
 
+// WriteTo writes the ini file data to w, using the File's Encoding.
+//
+// Satisfies the io.WriterTo interface.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	if f.Encoding == UTF8 {
+		return f.File.WriteTo(w)
+	}
+	var buf bytes.Buffer
+	if _, err := f.File.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	n, err := w.Write(f.Encoding.encode(buf.Bytes()))
+	return int64(n), err
+}
+
+// Write writes the ini file data to filename, using the File's Encoding.
+func (f *File) Write(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if _, err = f.WriteTo(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
 // Parse passes the filename/reader to ini.Parser.Parse.
-func Parse(name, filename string, r io.Reader) (*File, error) {
-	// sanitize data first (ensure file ends with '\n')
-	buf, err := fixEnding(r)
+//
+// When parsing with the parser.Lenient option, lines that cannot be parsed are
+// preserved in the File, and the File is returned along with a ParseErrors
+// list describing each unparseable line.
+//
+// Data starting with a UTF-8 or UTF-16 byte order mark is transcoded to UTF-8
+// before parsing, and the encoding is recorded as File.Encoding. Error
+// positions refer to the transcoded data.
+//
+// Data exceeding a limit set by the parser.MaxBytes, parser.MaxLineLength,
+// parser.MaxSections or parser.MaxKeys options is not parsed, and the returned
+// error wraps a *parser.LimitError.
+func Parse(name, filename string, r io.Reader, opts ...parser.Option) (*File, error) {
+	// read
+	buf, err := parser.ReadAll(r, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	// pass through ini/parser package
-	f, err := parser.Parse(name, buf)
+	// detect encoding and sanitize data (ensure file ends with '\n')
+	buf, enc, err := decode(buf)
 	if err != nil {
-		return nil, &ParseError{name, parser.LastError()}
+		return nil, newParseError(name, buf, endPosition(buf), err)
 	}
+	buf = fixEnding(buf)
 
-	// convert to *parser.File
-	inifile, ok := f.(*parser.File)
-	if !ok {
-		return nil, &ParseError{name, parser.LastError()}
+	// pass through ini/parser package, without the size limit (already
+	// checked when reading, and not including the added '\n')
+	f, pos, err := parser.Parse(name, buf, append(opts[:len(opts):len(opts)], parser.MaxBytes(0))...)
+	switch e := err.(type) {
+	case nil:
+	case parser.ErrorList:
+		if f == nil {
+			return nil, newParseError(name, buf, pos, err)
+		}
+		errs := make(ParseErrors, len(e))
+		for i, pe := range e {
+			errs[i] = newParseError(name, buf, pe.Pos, pe.Err)
+		}
+		err = errs
+	default:
+		return nil, newParseError(name, buf, pos, err)
 	}
 
 	return &File{
-		File:     inifile,
+		File:     f,
 		Filename: filename,
-	}, nil
+		Encoding: enc,
+	}, err
 }
 
 // Load loads ini file from a io.Reader.
-func Load(r io.Reader) (*File, error) {
-	return Parse("<io.Reader>", "", r)
+func Load(r io.Reader, opts ...parser.Option) (*File, error) {
+	return Parse("<io.Reader>", "", r, opts...)
 }
 
 // LoadBytes loads ini file from a byte slice.
-func LoadBytes(buf []byte) (*File, error) {
-	return Parse("<buffer>", "", bytes.NewReader(buf))
+func LoadBytes(buf []byte, opts ...parser.Option) (*File, error) {
+	return Parse("<buffer>", "", bytes.NewReader(buf), opts...)
 }
 
 // LoadString loads ini file from string.
-func LoadString(str string) (*File, error) {
-	return Parse("<string>", "", strings.NewReader(str))
+func LoadString(str string, opts ...parser.Option) (*File, error) {
+	return Parse("<string>", "", strings.NewReader(str), opts...)
 }
 
 // LoadFile loads ini data from a file with specified filename.
 //
 // If the filename doesn't exist, then an empty File is returned. The data can
 // then be written to disk using File.Save, or parser.File.Write.
-func LoadFile(filename string) (*File, error) {
+func LoadFile(filename string, opts ...parser.Option) (*File, error) {
 	// check if the file exists, return a new file if it doesn't
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		file := NewFile()
@@ -125,20 +267,14 @@ func LoadFile(filename string) (*File, error) {
 	}
 	defer f.Close()
 
-	return Parse(filename, filename, f)
+	return Parse(filename, filename, f, opts...)
 }
 
-// fixEnding fixes the file data in r, ensuring the file ends with \n.
-func fixEnding(r io.Reader) ([]byte, error) {
-	// read
-	buf, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-
+// fixEnding fixes the file data in buf, ensuring the file ends with \n.
+func fixEnding(buf []byte) []byte {
 	// add '\n' to end if not present
 	if len(buf) == 0 || buf[len(buf)-1] != '\n' {
-		return append(buf, '\n'), nil
+		return append(buf, '\n')
 	}
-	return buf, nil
+	return buf
 }
@@ -1,9 +1,20 @@
 package ini
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+
+	"github.com/kenshaw/ini/parser"
 )
 
 const (
@@ -597,12 +608,12 @@ func TestStringValues(t *testing.T) {
 	}
 
 	v0 := f.GetKey("k0")
-	if v0 != "\"v0;#notacomment\"" {
-		t.Errorf("v0 should be \"v0;#notacomment\"")
+	if v0 != "v0;#notacomment" {
+		t.Errorf("v0 should be v0;#notacomment")
 	}
 
 	v1 := f.GetKey("k1")
-	if v1 != "\"line0\nline2\"" {
+	if v1 != "line0\nline2" {
 		t.Error("k1 should span multiple lines")
 	}
 }
@@ -658,6 +669,50 @@ func TestSectionsWithSameName(t *testing.T) {
 		foundSections += 1
 	}
 
+	// addressing by index
+	if v := len(f.GetSections("section")); v != 2 {
+		t.Fatalf("expected 2 sections, got: %d", v)
+	}
+	if v := f.GetSectionAt("section", 1).Get("key"); v != "value1" {
+		t.Errorf("key in second section should be \"value1\", found %q", v)
+	}
+	if f.GetSectionAt("section", 2) != nil {
+		t.Error("third section should be nil")
+	}
+	f.GetSectionAt("section", 1).SetKey("key2", "value2")
+	if err := f.RenameSectionAt("section", 1, "other"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// append
+	s, err := f.AppendSection("section")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	s.SetKey("key", "value3")
+	if _, err := f.AppendSection(""); !errors.Is(err, parser.ErrInvalidSectionName) {
+		t.Errorf("expected ErrInvalidSectionName, got: %v", err)
+	}
+	d0 := "\n[section]\nkey=value\n\n[other]\nkey=value1\nkey2=value2\n[section]\n\tkey=value3\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+	m := f.GetMapAll()
+	if v := m["section"]; len(v) != 2 || v[0]["key"] != "value" || v[1]["key"] != "value3" {
+		t.Errorf("expected 2 sections in map, got: %v", v)
+	}
+
+	// remove
+	if err := f.RemoveSectionAt("section", 1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := f.RemoveSectionAt("section", 1); !errors.Is(err, parser.ErrSectionNotFound) {
+		t.Errorf("expected ErrSectionNotFound, got: %v", err)
+	}
+	d1 := "\n[section]\nkey=value\n\n[other]\nkey=value1\nkey2=value2\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
 }
 
 func TestMaps(t *testing.T) {
@@ -772,3 +827,1772 @@ func TestLoadFileSave(t *testing.T) {
 		t.Error("filename should be nonexistent")
 	}
 }
+
+func TestEncodings(t *testing.T) {
+	d0 := "k0=v0\r\n[sect1]\r\nk1=\u00e9\U0001d11e\r\n"
+	utf16le := []byte{0xff, 0xfe}
+	utf16be := []byte{0xfe, 0xff}
+	for _, r := range d0 {
+		if r > 0xffff {
+			// surrogate pair for U+1D11E
+			utf16le = append(utf16le, 0x34, 0xd8, 0x1e, 0xdd)
+			utf16be = append(utf16be, 0xd8, 0x34, 0xdd, 0x1e)
+			continue
+		}
+		utf16le = append(utf16le, byte(r), byte(r>>8))
+		utf16be = append(utf16be, byte(r>>8), byte(r))
+	}
+	tests := []struct {
+		enc Encoding
+		buf []byte
+	}{
+		{UTF8, []byte(d0)},
+		{UTF8BOM, append([]byte{0xef, 0xbb, 0xbf}, d0...)},
+		{UTF16LE, utf16le},
+		{UTF16BE, utf16be},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		f, err := LoadBytes(test.buf)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if f.Encoding != test.enc {
+			t.Errorf("test %d expected encoding %v, got: %v", i, test.enc, f.Encoding)
+		}
+		if v := f.GetKey("k0"); v != "v0" {
+			t.Errorf("test %d k0 should be v0, got: %q", i, v)
+		}
+		if v := f.GetKey("sect1.k1"); v != "\u00e9\U0001d11e" {
+			t.Errorf("test %d sect1.k1 should be %q, got: %q", i, "\u00e9\U0001d11e", v)
+		}
+		if d0 != f.String() {
+			t.Errorf("test %d expected %q, got: %q", i, d0, f.String())
+		}
+
+		// round trip
+		var buf bytes.Buffer
+		if _, err := f.WriteTo(&buf); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if !bytes.Equal(test.buf, buf.Bytes()) {
+			t.Errorf("test %d expected:\n%x\ngot:\n%x", i, test.buf, buf.Bytes())
+		}
+
+		// save with changes
+		f.Filename = filepath.Join(dir, fmt.Sprintf("%d.ini", i))
+		f.SetKey("sect1.k2", "v2")
+		if err := f.Save(); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		g, err := LoadFile(f.Filename)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if g.Encoding != test.enc || g.GetKey("sect1.k2") != "v2" || g.String() != f.String() {
+			t.Errorf("test %d expected %v %q, got: %v %q", i, test.enc, f.String(), g.Encoding, g.String())
+		}
+	}
+
+	// odd length utf-16
+	_, err := LoadBytes(append(utf16le, 'x'))
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, parser.ErrInvalidEncoding) || pe.Line != 4 || pe.Column != 1 {
+		t.Errorf("expected invalid encoding error on line 4:1, got: %v", err)
+	}
+
+	// bom only
+	f, err := LoadBytes([]byte{0xef, 0xbb, 0xbf})
+	if err != nil || f.Encoding != UTF8BOM {
+		t.Errorf("expected empty file with bom, got: %v, %v", f, err)
+	}
+}
+
+// test that concurrent parses do not share parse state (run with -race)
+func TestConcurrentParse(t *testing.T) {
+	inputs := []string{
+		"[sect1]\nk1=v1\n[bad\n",
+		"k0=v0\n\n\n\n[sect2]\n]bad\n",
+		"[sect3]\nk3=v3\nk4=v4\nk5=v5\n[\n",
+		"k0=v0\n[sect4]\nk6=v6\n",
+	}
+
+	// determine expected errors serially
+	exp := make([]string, len(inputs))
+	for i, s := range inputs {
+		if _, err := LoadString(s); err != nil {
+			exp[i] = err.Error()
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 100*len(inputs))
+	for n := 0; n < 100; n++ {
+		for i, s := range inputs {
+			wg.Add(1)
+			go func(i int, s string) {
+				defer wg.Done()
+				var msg string
+				if _, err := LoadString(s); err != nil {
+					msg = err.Error()
+				}
+				if msg != exp[i] {
+					errs <- fmt.Sprintf("input %d expected error %q, got: %q", i, exp[i], msg)
+				}
+			}(i, s)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := LoadString("k0=v0\n[sect1]\n\t[bad section\nk1=v1\n")
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error should be a *ParseError, got: %T", err)
+	}
+	if pe.Filename != "<string>" {
+		t.Errorf("filename should be <string>, got: %q", pe.Filename)
+	}
+	if pe.Line != 3 || pe.Column != 14 || pe.Offset != 27 {
+		t.Errorf("error should be on line 3:14 (offset 27), got: %d:%d (offset %d)", pe.Line, pe.Column, pe.Offset)
+	}
+	if pe.Source != "\t[bad section" {
+		t.Errorf("source should be the offending line, got: %q", pe.Source)
+	}
+	if caret := pe.Caret(); caret != "\t            ^" {
+		t.Errorf("caret should point to end of line, got: %q", caret)
+	}
+	if errors.Unwrap(err) == nil {
+		t.Error("ParseError should wrap the underlying error")
+	}
+}
+
+func TestLenientParse(t *testing.T) {
+	d0 := "k0=v0\n[sect1\nk1 = v1 ; comment\n  ]junk\n[sect2]\nk2=v2\n"
+	if _, err := LoadString(d0); err == nil {
+		t.Fatal("strict parse should fail")
+	}
+
+	f, err := LoadString(d0, parser.Lenient(true))
+	if f == nil {
+		t.Fatalf("lenient parse should return file, got error: %v", err)
+	}
+
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error should be ParseErrors, got: %T", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %d", len(errs))
+	}
+	if errs[0].Line != 2 || errs[0].Column != 7 || errs[0].Source != "[sect1" {
+		t.Errorf("first error should be on line 2:7, got: %d:%d %q", errs[0].Line, errs[0].Column, errs[0].Source)
+	}
+	if errs[1].Line != 4 || errs[1].Column != 3 || errs[1].Source != "  ]junk" {
+		t.Errorf("second error should be on line 4:3, got: %d:%d %q", errs[1].Line, errs[1].Column, errs[1].Source)
+	}
+
+	// check values
+	if v := f.GetKey("k1"); v != "v1" {
+		t.Errorf("k1 should be v1, got: %q", v)
+	}
+	if v := f.GetKey("sect2.k2"); v != "v2" {
+		t.Errorf("sect2.k2 should be v2, got: %q", v)
+	}
+
+	// check round trip
+	if d0 != f.String() {
+		t.Errorf("lenient parse should preserve invalid lines, got: %q", f.String())
+	}
+
+	f.SetKey("sect2.k3", "v3")
+	d1 := "k0=v0\n[sect1\nk1 = v1 ; comment\n  ]junk\n[sect2]\nk2=v2\nk3=v3\n"
+	if d1 != f.String() {
+		t.Errorf("expected %q, got: %q", d1, f.String())
+	}
+}
+
+func TestParseLineEndings(t *testing.T) {
+	d0 := "k0=v0\r\n[sect1]\r\nk1 = v1 ; comment\r\n\r\nk2=v2\r\n"
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := f.GetKey("sect1.k1"); v != "v1" {
+		t.Errorf("sect1.k1 should be v1, got: %q", v)
+	}
+	if v := f.GetKey("sect1.k2"); v != "v2" {
+		t.Errorf("sect1.k2 should be v2, got: %q", v)
+	}
+	if d0 != f.String() {
+		t.Errorf("line endings should be preserved, got: %q", f.String())
+	}
+
+	_, err = LoadString("k0=v0\n[sect1\n")
+	if !errors.Is(err, parser.ErrSyntax) {
+		t.Errorf("error should be ErrSyntax, got: %v", err)
+	}
+	_, err = LoadString("k0=v0\nk1=\xff\n", parser.Lenient(true))
+	if !errors.Is(err, parser.ErrInvalidEncoding) {
+		t.Errorf("error should be ErrInvalidEncoding, got: %v", err)
+	}
+}
+
+func TestSetKeyQuoting(t *testing.T) {
+	f := NewFile()
+	f.SetKey("k0", "a;b#c")
+	f.SetKey("sect1.k1", "http://example.com/#fragment")
+	f.SetKey("sect1.k2", "\"quoted\" value")
+	f.SetKey("sect1.k3", "line0\nline1\r\nline2")
+	f.SetKey("sect1.k4", "tab\there \\ back\\slash \x01")
+	f.SetKey("sect1.k5", "say \"hi\"")
+	f.SetKey("sect1.k6", "日本語; ok")
+	f.GetSection("sect1").SetKeyValueRaw("k7", "  padded  ")
+	f.GetSection("sect1").SetKeyValueRaw("k8", "\"already;quoted\"")
+
+	d0 := "k0=\"a;b#c\"\n[sect1]\n\tk1=\"http://example.com/#fragment\"\n\tk2=\"\\\"quoted\\\" value\"\n\tk3=\"line0\\nline1\\r\\nline2\"\n\tk4=tab\there \\ back\\slash \x01\n\tk5=say \"hi\"\n\tk6=\"日本語; ok\"\n\tk7=\"  padded  \"\n\tk8=\"\\\"already;quoted\\\"\"\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// reload and check values
+	g, err := LoadString(f.String())
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	exp := map[string]string{
+		"k0":       "a;b#c",
+		"sect1.k1": "http://example.com/#fragment",
+		"sect1.k2": "\"quoted\" value",
+		"sect1.k3": "line0\nline1\r\nline2",
+		"sect1.k4": "tab\there \\ back\\slash \x01",
+		"sect1.k5": "say \"hi\"",
+		"sect1.k6": "日本語; ok",
+		"sect1.k7": "  padded  ",
+		"sect1.k8": "\"already;quoted\"",
+	}
+	for key, val := range exp {
+		if v := g.GetKey(key); v != val {
+			t.Errorf("after reload, %s should be %q, got: %q", key, val, v)
+		}
+	}
+
+	// leading and trailing whitespace is preserved, literal quotes are kept
+	f = NewFile()
+	f.SetKey("k0", "  padded\t")
+	f.SetKey("k1", "\"x\"")
+	f.SetKey("k2", "  plain  value")
+	f.SetKey("k3", "trimmed")
+	if err := f.GetSection("").AddValue("k3", " another "); err != nil {
+		t.Fatalf("could not add value: %v", err)
+	}
+	g, err = LoadString(f.String())
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if v := g.GetKey("k0"); v != "  padded\t" {
+		t.Errorf("k0 should preserve whitespace, got: %q", v)
+	}
+	if v := g.GetKey("k1"); v != "\"x\"" {
+		t.Errorf("k1 should be the literal %q, got: %q", "\"x\"", v)
+	}
+	if v := g.GetKey("k2"); v != "  plain  value" {
+		t.Errorf("k2 should preserve whitespace, got: %q", v)
+	}
+	if v := g.GetSection("").GetAll("k3"); !reflect.DeepEqual(v, []string{"trimmed", " another "}) {
+		t.Errorf("k3 should be [trimmed, \" another \"], got: %q", v)
+	}
+
+	// check existing comment is preserved on quoted value
+	h, err := LoadString("k0 = v0 ; comment\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	h.SetKey("k0", "a;b")
+	if d1 := "k0 = \"a;b\"; comment\n"; d1 != h.String() {
+		t.Errorf("expected %q, got: %q", d1, h.String())
+	}
+}
+
+func TestQuotedValues(t *testing.T) {
+	data := `k0 = "caf\u00e9 \ud83d\ude00"
+k1 = "a\/b \"c\" \\d\te"
+k2 = "bad\q" x
+k3 = plain ; comment
+
+[sect1]
+k4 = "x;y" ; comment
+`
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	exp := map[string]string{
+		"k0":       "café 😀",
+		"k1":       "a/b \"c\" \\d\te",
+		"k2":       "\"bad\\q\" x",
+		"k3":       "plain",
+		"sect1.k4": "x;y",
+	}
+	for key, val := range exp {
+		if v := f.GetKey(key); v != val {
+			t.Errorf("%s should be %q, got: %q", key, val, v)
+		}
+	}
+
+	// raw values keep literal text
+	if v := f.GetKeyRaw("k1"); v != `"a\/b \"c\" \\d\te"` {
+		t.Errorf("raw k1 should be literal text, got: %q", v)
+	}
+	if v := f.GetKeyRaw("sect1.k4"); v != `"x;y" ` {
+		t.Errorf("raw sect1.k4 should be literal text, got: %q", v)
+	}
+
+	// maps decode values
+	m := f.GetMap()
+	for key, val := range exp {
+		name, k := parser.NameSplitFunc(key)
+		if m[name][k] != val {
+			t.Errorf("GetMap %s should be %q, got: %q", key, val, m[name][k])
+		}
+	}
+	if v := f.GetMapFlat()["sect1.k4 "]; v != "x;y" {
+		t.Errorf("GetMapFlat sect1.k4 should be x;y, got: %q", v)
+	}
+
+	// flat maps report raw keys
+	g, err := LoadString("Key0=v0\n[Sect1]\nMixed_Key=v1\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if exp := map[string]string{"Key0": "v0", "sect1.Mixed_Key": "v1"}; !reflect.DeepEqual(exp, g.GetMapFlat()) {
+		t.Errorf("GetMapFlat should be %v, got: %v", exp, g.GetMapFlat())
+	}
+	if exp := []string{"Key0", "v0", "sect1.Mixed_Key", "v1"}; !reflect.DeepEqual(exp, g.GetAllFlat()) {
+		t.Errorf("GetAllFlat should be %v, got: %v", exp, g.GetAllFlat())
+	}
+
+	// exported helpers
+	if v, err := parser.Unquote(parser.Quote("a\"b\\c\n\x01")); err != nil || v != "a\"b\\c\n\x01" {
+		t.Errorf("Unquote(Quote) should round trip, got: %q, %v", v, err)
+	}
+	if _, err := parser.Unquote(`"bad\q"`); err != parser.ErrInvalidQuotedValue {
+		t.Errorf("expected ErrInvalidQuotedValue, got: %v", err)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	f := NewFile()
+	f.SetKey("k0", "v0")
+
+	if s, err := f.AddSectionErr("a]b"); !errors.Is(err, parser.ErrInvalidSectionName) || s != nil {
+		t.Errorf("expected ErrInvalidSectionName, got: %v", err)
+	}
+	if s := f.AddSection("a;b"); s != nil {
+		t.Errorf("AddSection with invalid name should return nil")
+	}
+	if err := f.SetKeyErr("x=y", "v"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	if err := f.SetKeyErr("sect1.a\nb", "v"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	if err := f.GetSection("").SetKeyValueRawErr(" k", "v"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	if err := f.RenameSectionErr("sect2", "sect3"); !errors.Is(err, parser.ErrSectionNotFound) {
+		t.Errorf("expected ErrSectionNotFound, got: %v", err)
+	}
+	f.SetKey("x[y", "v")
+	f.SetKey("a#b.k", "v")
+
+	// file should be unchanged, and no section created for an invalid key
+	if d0 := "k0=v0\n"; d0 != f.String() {
+		t.Errorf("expected %q, got: %q", d0, f.String())
+	}
+
+	f.SetKey("sect1.k1", "v1")
+	if err := f.RenameSectionErr("sect1", "sect[2]"); !errors.Is(err, parser.ErrInvalidSectionName) {
+		t.Errorf("expected ErrInvalidSectionName, got: %v", err)
+	}
+	f.RenameSection("sect1", "sect\n2")
+	for _, name := range []string{"", "   ", "\t"} {
+		if err := f.RenameSectionErr("sect1", name); !errors.Is(err, parser.ErrInvalidSectionName) {
+			t.Errorf("%q: expected ErrInvalidSectionName, got: %v", name, err)
+		}
+		if err := f.RenameSectionRawErr("sect1", name); !errors.Is(err, parser.ErrInvalidSectionName) {
+			t.Errorf("%q: expected ErrInvalidSectionName, got: %v", name, err)
+		}
+		if err := f.RenameSectionAt("sect1", 0, name); !errors.Is(err, parser.ErrInvalidSectionName) {
+			t.Errorf("%q: expected ErrInvalidSectionName, got: %v", name, err)
+		}
+	}
+	if err := f.RenameSectionErr("sect1", "sect 2"); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+
+	d1 := "k0=v0\n[sect 2]\n\tk1=v1\n"
+	if d1 != f.String() {
+		t.Errorf("expected %q, got: %q", d1, f.String())
+	}
+	if _, err := LoadString(f.String()); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	data := `# file comment
+
+# server doc
+# second line
+[server] # server comment
+	# host doc
+	host = localhost # host comment
+	port = 80
+	flag
+`
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	s := f.GetSection("server")
+	if v := s.Comment(); v != "server comment" {
+		t.Errorf("section comment should be 'server comment', got: %q", v)
+	}
+	if v := s.DocComment(); v != "server doc\nsecond line" {
+		t.Errorf("section doc comment should be 'server doc\\nsecond line', got: %q", v)
+	}
+	if v := s.KeyComment("host"); v != "host comment" {
+		t.Errorf("host comment should be 'host comment', got: %q", v)
+	}
+	if v := s.KeyDocComment("host"); v != "host doc" {
+		t.Errorf("host doc comment should be 'host doc', got: %q", v)
+	}
+	if v := s.KeyDocComment("port"); v != "" {
+		t.Errorf("port should not have a doc comment, got: %q", v)
+	}
+	if v := f.GetSection("").DocComment(); v != "" {
+		t.Errorf("empty section should not have a doc comment, got: %q", v)
+	}
+
+	// change comments
+	if err := s.SetComment("new server comment"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	s.SetDocComment("new server doc")
+	if err := s.SetKeyComment("host", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetKeyComment("port", "port comment"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetKeyComment("flag", "flag comment"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetKeyDocComment("host", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetKeyDocComment("port", "port doc\nline 2"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	d0 := `# file comment
+
+# new server doc
+[server] # new server comment
+	host = localhost
+	# port doc
+	# line 2
+	port = 80 # port comment
+	flag # flag comment
+`
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// errors
+	if err := s.SetKeyComment("missing", "c"); !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got: %v", err)
+	}
+	if err := s.SetKeyDocComment("missing", "c"); !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got: %v", err)
+	}
+	if err := s.SetComment("a\nb"); !errors.Is(err, parser.ErrInvalidComment) {
+		t.Errorf("expected ErrInvalidComment, got: %v", err)
+	}
+
+	// default comment separator
+	g := NewFile()
+	g.SetKey("sect1.k0", "v0")
+	g.GetSection("sect1").SetComment("c0")
+	g.GetSection("sect1").SetKeyDocComment("k0", "c1")
+	g.GetSection("sect1").SetKeyComment("k0", "c2")
+	if d1 := "[sect1] ; c0\n\t; c1\n\tk0=v0 ; c2\n"; d1 != g.String() {
+		t.Errorf("expected %q, got: %q", d1, g.String())
+	}
+}
+
+func TestDocCommentAttachment(t *testing.T) {
+	data := `; file comment
+
+; sect1 doc
+[sect1]
+; k0 doc
+k0 = v0
+k1 = v1
+
+; sect2 doc
+[sect2]
+; k2 doc
+k2 = v2 ; k2 comment
+`
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	// add key before next section's doc comment
+	f.SetKey("sect1.k3", "v3")
+	d0 := "; file comment\n\n; sect1 doc\n[sect1]\n; k0 doc\nk0 = v0\nk1 = v1\nk3=v3\n\n; sect2 doc\n[sect2]\n; k2 doc\nk2 = v2 ; k2 comment\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// remove key with doc comment
+	f.RemoveKey("sect1.k0")
+	d1 := "; file comment\n\n; sect1 doc\n[sect1]\nk1 = v1\nk3=v3\n\n; sect2 doc\n[sect2]\n; k2 doc\nk2 = v2 ; k2 comment\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+
+	// rename key
+	s1 := f.GetSection("sect1")
+	if err := s1.RenameKey("k3", "k4"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s1.RenameKey("k4", "k1"); !errors.Is(err, parser.ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got: %v", err)
+	}
+	if err := s1.RenameKey("missing", "k5"); !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got: %v", err)
+	}
+	if v := f.GetKey("sect1.k4"); v != "v3" {
+		t.Errorf("sect1.k4 should be v3, got: %q", v)
+	}
+
+	// move key with comments
+	if err := f.GetSection("sect2").MoveKey("k2", s1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d2 := "; file comment\n\n; sect1 doc\n[sect1]\nk1 = v1\nk4=v3\n; k2 doc\nk2 = v2 ; k2 comment\n\n; sect2 doc\n[sect2]\n"
+	if d2 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d2, f.String())
+	}
+	if v := f.GetKey("sect1.k2"); v != "v2" {
+		t.Errorf("sect1.k2 should be v2, got: %q", v)
+	}
+	if v := f.GetSection("sect2").Keys(); len(v) != 0 {
+		t.Errorf("sect2 should have no keys, got: %v", v)
+	}
+
+	// move key to a nil section, or a section of another file
+	other := NewFile()
+	for _, dest := range []*parser.Section{nil, other.AddSection("sect2")} {
+		if err := s1.MoveKey("k2", dest); !errors.Is(err, parser.ErrSectionNotFound) {
+			t.Errorf("expected ErrSectionNotFound, got: %v", err)
+		}
+	}
+	if d2 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d2, f.String())
+	}
+	if v := other.String(); v != "[sect2]\n" {
+		t.Errorf("other file should be unchanged, got: %q", v)
+	}
+
+	// move section with doc comment
+	if err := f.MoveSection("sect2", "sect1"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d3 := "; file comment\n\n; sect2 doc\n[sect2]\n; sect1 doc\n[sect1]\nk1 = v1\nk4=v3\n; k2 doc\nk2 = v2 ; k2 comment\n\n"
+	if d3 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d3, f.String())
+	}
+	if v := f.SectionNames(); !reflect.DeepEqual(v, []string{"", "sect2", "sect1"}) {
+		t.Errorf("section names should be [ sect2 sect1], got: %v", v)
+	}
+	if err := f.MoveSection("", "sect1"); !errors.Is(err, parser.ErrSectionNotFound) {
+		t.Errorf("expected ErrSectionNotFound, got: %v", err)
+	}
+
+	// remove section preserving next section's doc comment
+	f.RemoveSection("sect2")
+	d4 := "; file comment\n\n; sect1 doc\n[sect1]\nk1 = v1\nk4=v3\n; k2 doc\nk2 = v2 ; k2 comment\n\n"
+	if d4 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d4, f.String())
+	}
+
+	// move section to end
+	f.SetKey("sect3.k5", "v5")
+	if err := f.MoveSection("sect1", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d5 := "; file comment\n\n[sect3]\n\tk5=v5\n; sect1 doc\n[sect1]\nk1 = v1\nk4=v3\n; k2 doc\nk2 = v2 ; k2 comment\n"
+	if d5 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d5, f.String())
+	}
+}
+
+func TestMultiValues(t *testing.T) {
+	data := `[remote "origin"]
+	url = git@example.com:repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	; tags
+	fetch = +refs/tags/*:refs/tags/*
+	prune = true
+`
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	GitDialect(f)
+
+	fetch := []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}
+	if v := f.GetAll("remote.origin.fetch"); !reflect.DeepEqual(v, fetch) {
+		t.Errorf("expected %v, got: %v", fetch, v)
+	}
+	if v := f.GetAll("remote.origin.missing"); v != nil {
+		t.Errorf("expected nil, got: %v", v)
+	}
+
+	// add
+	if err := f.AddValue("remote.origin.fetch", "+refs/pull/*:refs/pull/*"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := f.AddValue("remote.upstream.fetch", "+refs/heads/*:refs/remotes/upstream/*"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d0 := "[remote \"origin\"]\n\turl = git@example.com:repo.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n\t; tags\n\tfetch = +refs/tags/*:refs/tags/*\n\tfetch=+refs/pull/*:refs/pull/*\n\tprune = true\n[remote \"upstream\"]\n\tfetch=+refs/heads/*:refs/remotes/upstream/*\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// unset with value regex
+	if err := f.UnsetAll("remote.origin.fetch", "^\\+refs/(tags|pull)/"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d1 := "[remote \"origin\"]\n\turl = git@example.com:repo.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n\tprune = true\n[remote \"upstream\"]\n\tfetch=+refs/heads/*:refs/remotes/upstream/*\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+	if v := f.GetSection("remote.origin").Keys(); !reflect.DeepEqual(v, []string{"url", "fetch", "prune"}) {
+		t.Errorf("expected [url fetch prune], got: %v", v)
+	}
+
+	// replace all
+	f.AddValue("remote.origin.fetch", "a")
+	f.AddValue("remote.origin.fetch", "b")
+	if err := f.ReplaceAll("remote.origin.fetch", "c;d", "!^\\+"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	fetch = []string{"+refs/heads/*:refs/remotes/origin/*", "c;d"}
+	if v := f.GetAll("remote.origin.fetch"); !reflect.DeepEqual(v, fetch) {
+		t.Errorf("expected %v, got: %v", fetch, v)
+	}
+	if err := f.ReplaceAll("remote.origin.fetch", "e", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d2 := "[remote \"origin\"]\n\turl = git@example.com:repo.git\n\tfetch = e\n\tprune = true\n[remote \"upstream\"]\n\tfetch=+refs/heads/*:refs/remotes/upstream/*\n"
+	if d2 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d2, f.String())
+	}
+
+	// no match adds key
+	if err := f.ReplaceAll("remote.origin.tagopt", "--no-tags", "x"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := f.GetKey("remote.origin.tagopt"); v != "--no-tags" {
+		t.Errorf("expected --no-tags, got: %q", v)
+	}
+
+	// replace key-only entries
+	g, err := LoadString("[s]\nflag\nk: v\nflag ; comment\n", parser.Delimiters(":"))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if err := g.ReplaceAll("s.flag", "x", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if d3 := "[s]\nflag:x\nk: v\n"; d3 != g.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d3, g.String())
+	}
+	g, err = LoadString("[s]\nflag ; comment\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if err := g.ReplaceAll("s.flag", "", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if d4 := "[s]\nflag = ; comment\n"; d4 != g.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d4, g.String())
+	}
+
+	// bad regex
+	if err := f.UnsetAll("remote.origin.fetch", "("); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	data := `[mysqld]
+skip-name-resolve
+bind-address =
+port = 3306
+quick ; comment
+`
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	s := f.GetSection("mysqld")
+	tests := []struct {
+		key            string
+		hasKey, hasVal bool
+	}{
+		{"skip-name-resolve", true, false},
+		{"bind-address", true, true},
+		{"port", true, true},
+		{"quick", true, false},
+		{"missing", false, false},
+	}
+	for _, test := range tests {
+		if v := s.HasKey(test.key); v != test.hasKey {
+			t.Errorf("HasKey(%q) should be %t", test.key, test.hasKey)
+		}
+		if v := s.HasValue(test.key); v != test.hasVal {
+			t.Errorf("HasValue(%q) should be %t", test.key, test.hasVal)
+		}
+	}
+	if b, err := s.GetBool("skip-name-resolve"); err != nil || !b {
+		t.Errorf("GetBool for flag should be true, got: %t, %v", b, err)
+	}
+
+	m := f.GetMap()["mysqld"]
+	if m["skip-name-resolve"] != "true" || m["bind-address"] != "" {
+		t.Errorf("flags should be reported as true, got: %v", m)
+	}
+
+	var cfg struct {
+		MySQLd struct {
+			SkipNameResolve bool   `ini:"skip-name-resolve"`
+			BindAddress     string `ini:"bind-address"`
+		} `ini:"mysqld"`
+	}
+	if err := f.Decode(&cfg); err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	if !cfg.MySQLd.SkipNameResolve {
+		t.Error("skip-name-resolve should be true")
+	}
+
+	// set flags
+	if err := s.SetFlag("port"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetFlag("skip-networking"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.SetFlag("a=b"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	s.SetKey("quick", "1")
+	d0 := "[mysqld]\nskip-name-resolve\nbind-address =\nport \nquick =1 ; comment\nskip-networking\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// reload
+	g, err := LoadString(f.String())
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if g.GetSection("mysqld").HasValue("port") {
+		t.Error("port should not have a value after reload")
+	}
+}
+
+func TestContinuation(t *testing.T) {
+	// backslash
+	d0 := "[a]\nkey = one \\\n    two \\\n  three ; comment\nnext = 1\n"
+	f, err := LoadString(d0, parser.Continuation(parser.BackslashContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	s := f.GetSection("a")
+	if v := s.Get("key"); v != "one two three" {
+		t.Errorf("expected %q, got: %q", "one two three", v)
+	}
+	if v := s.KeyComment("key"); v != "comment" {
+		t.Errorf("expected comment, got: %q", v)
+	}
+	if v := s.Get("next"); v != "1" {
+		t.Errorf("expected 1, got: %q", v)
+	}
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// not a continuation without the option
+	g, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if v := g.GetSection("a").Get("key"); v != `one \` {
+		t.Errorf("expected %q, got: %q", `one \`, v)
+	}
+
+	// set values ending with a backslash
+	s.SetKey("next", `C:\dir\`)
+	s.SetKey("path", `D:\`)
+	s.SetKey("last", "1")
+	d3 := "[a]\nkey = one \\\n    two \\\n  three ; comment\nnext = \"C:\\\\dir\\\\\"\npath=\"D:\\\\\"\nlast=1\n"
+	if d3 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d3, f.String())
+	}
+	g, err = LoadString(f.String(), parser.Continuation(parser.BackslashContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for key, exp := range map[string]string{"key": "one two three", "next": `C:\dir\`, "path": `D:\`, "last": "1"} {
+		if v := g.GetSection("a").Get(key); v != exp {
+			t.Errorf("%s after reload expected %q, got: %q", key, exp, v)
+		}
+	}
+
+	// indent
+	d1 := "[a]\npaths = /usr\n  /opt\n\t/home\nnext = 1\n[b]\n  k = v\n  k2 = v2\n"
+	f, err = LoadString(d1, parser.Continuation(parser.IndentContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if v := f.GetKey("a.paths"); v != "/usr\n/opt\n/home" {
+		t.Errorf("expected joined lines, got: %q", v)
+	}
+	if v := f.GetKey("a.next"); v != "1" {
+		t.Errorf("expected 1, got: %q", v)
+	}
+	if v := f.GetKey("b.k2"); v != "v2" {
+		t.Errorf("keys with the same indent should not be continued, got: %q", v)
+	}
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+
+	// set multi-line values
+	f.SetKey("a.next", "x\ny")
+	f.SetKey("b.k", "v")
+	f.SetKey("a.paths", "/bin")
+	f.SetKey("a.new", "a\n b")
+	d2 := "[a]\npaths = /bin\nnext = x\n\ty\nnew=\"a\\n b\"\n[b]\n  k = v\n  k2 = v2\n"
+	if d2 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d2, f.String())
+	}
+	g, err = LoadString(f.String(), parser.Continuation(parser.IndentContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for _, key := range []string{"a.paths", "a.next", "a.new"} {
+		if v, exp := g.GetKey(key), f.GetKey(key); v != exp {
+			t.Errorf("%s after reload expected %q, got: %q", key, exp, v)
+		}
+	}
+
+	// values that can not be written as continuation lines are quoted, and
+	// keys set to a value do not continue onto more indented lines
+	d4 := "[a]\nflag\n  k = v\n  [b]\n  flag2\n    k2 = v2\n[c]\n  [d]\n"
+	f, err = LoadString(d4, parser.Continuation(parser.IndentContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	values := []struct {
+		key, value string
+	}{
+		{"a.flag", "1"},
+		{"a.lead", "\na"},
+		{"a.crlf", "a\r\nb"},
+		{"a.empty", "a\n\nb"},
+		{"b.flag2", "x\ny"},
+		{"c.k", "1"},
+	}
+	for _, v := range values {
+		f.SetKey(v.key, v.value)
+	}
+	d5 := "[a]\nflag=1\nk = v\nlead=\"\\na\"\ncrlf=\"a\\r\\nb\"\nempty=\"a\\n\\nb\"\n[b]\nflag2=x\n\ty\nk2 = v2\n[c]\n\tk=1\n\t[d]\n"
+	if d5 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d5, f.String())
+	}
+	g, err = LoadString(f.String(), parser.Continuation(parser.IndentContinuation))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for _, v := range append(values, struct{ key, value string }{"a.k", "v"}, struct{ key, value string }{"b.k2", "v2"}) {
+		if s := g.GetKey(v.key); s != v.value {
+			t.Errorf("%s after reload expected %q, got: %q", v.key, v.value, s)
+		}
+	}
+}
+
+func TestDelimiters(t *testing.T) {
+	d0 := "[a]\nk1: v1\nk2 = v2\nk3:v3\nk4=\n"
+	f, err := LoadString(d0, parser.Delimiters("=:"))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for key, exp := range map[string]string{"a.k1": "v1", "a.k2": "v2", "a.k3": "v3", "a.k4": ""} {
+		if v := f.GetKey(key); v != exp {
+			t.Errorf("%s expected %q, got: %q", key, exp, v)
+		}
+	}
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// new keys use the dominant delimiter
+	f.SetKey("a.k5", "v5")
+	if err := f.SetKeyErr("a.k:6", "v6"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	d1 := d0 + "k5:v5\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+
+	// ':' is part of the key by default
+	g, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if s := g.GetSection("a"); s.HasValue("k1") || !s.HasKey("k1: v1") {
+		t.Errorf("expected k1: v1 to be a key-only entry, got: %v", s.RawKeys())
+	}
+
+	// whitespace
+	d2 := "[a]\nk1 v1\nk2\tv2 ; comment\nk3 = v3\nflag\nflag2 ; comment\n"
+	f, err = LoadString(d2, parser.Delimiters("=: "))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for key, exp := range map[string]string{"a.k1": "v1", "a.k2": "v2", "a.k3": "v3"} {
+		if v := f.GetKey(key); v != exp {
+			t.Errorf("%s expected %q, got: %q", key, exp, v)
+		}
+	}
+	s := f.GetSection("a")
+	if s.HasValue("flag") || s.HasValue("flag2") {
+		t.Error("flag and flag2 should be key-only entries")
+	}
+	if d2 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d2, f.String())
+	}
+	f.SetKey("a.k4", "v4")
+	f.SetKey("a.k5", "")
+	d3 := d2 + "k4 v4\nk5 \"\"\n"
+	if d3 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d3, f.String())
+	}
+	g, err = LoadString(f.String(), parser.Delimiters("=: "))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if s := g.GetSection("a"); !s.HasValue("k5") || s.Get("k5") != "" {
+		t.Errorf("k5 should have an empty value after reload, got: %q", s.Get("k5"))
+	}
+
+	// values starting with a delimiter after a whitespace delimiter
+	values := map[string]string{"a.k1": "=z", "a.k6": "=x", "a.k7": "=\"é", "a.k8": ":y", "a.flag": "=f"}
+	for key, value := range values {
+		f.SetKey(key, value)
+	}
+	g, err = LoadString(f.String(), parser.Delimiters("=: "))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	for key, exp := range values {
+		if v := g.GetKey(key); v != exp {
+			t.Errorf("%s after reload expected %q, got: %q", key, exp, v)
+		}
+	}
+	if v := g.GetSection("a").GetRaw("k6"); v != `"=x"` {
+		t.Errorf("expected k6 to be quoted, got: %q", v)
+	}
+}
+
+func TestCommentPrefixes(t *testing.T) {
+	d0 := "! comment\n// another\n[a]\nurl = x/#a ! inline\nk;1 = v;1\n"
+	f, err := LoadString(d0, parser.CommentPrefixes("!", "//"))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	s := f.GetSection("a")
+	if v := s.Get("url"); v != "x/#a" {
+		t.Errorf("expected %q, got: %q", "x/#a", v)
+	}
+	if v := s.KeyComment("url"); v != "inline" {
+		t.Errorf("expected inline comment, got: %q", v)
+	}
+	if v := s.Get("k;1"); v != "v;1" {
+		t.Errorf("expected %q, got: %q", "v;1", v)
+	}
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// new values and comments use the File's prefixes
+	f.SetKey("a.k2", "x!y")
+	if err := s.SetKeyComment("k2", "new"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := f.SetKeyErr("a.k!3", "v"); !errors.Is(err, parser.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got: %v", err)
+	}
+	d1 := d0 + "k2=\"x!y\" ! new\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+}
+
+func TestInlineComments(t *testing.T) {
+	d0 := "[a]\ncolor = #ff0000\nurl = http://x/#a ; comment\nk = ;empty\n"
+	tests := []struct {
+		policy                 parser.InlineCommentPolicy
+		color, url, k, comment string
+	}{
+		{parser.InlineCommentAnywhere, "", "http://x/", "", "a ; comment"},
+		{parser.InlineCommentWhitespace, "", "http://x/#a", "", "comment"},
+		{parser.InlineCommentNone, "#ff0000", "http://x/#a ; comment", ";empty", ""},
+	}
+	for i, test := range tests {
+		f, err := LoadString(d0, parser.InlineComments(test.policy))
+		if err != nil {
+			t.Fatalf("test %d could not load string: %v", i, err)
+		}
+		s := f.GetSection("a")
+		if v := s.Get("color"); v != test.color {
+			t.Errorf("test %d color expected %q, got: %q", i, test.color, v)
+		}
+		if v := s.Get("url"); v != test.url {
+			t.Errorf("test %d url expected %q, got: %q", i, test.url, v)
+		}
+		if v := s.Get("k"); v != test.k {
+			t.Errorf("test %d k expected %q, got: %q", i, test.k, v)
+		}
+		if v := s.KeyComment("url"); v != test.comment {
+			t.Errorf("test %d comment expected %q, got: %q", i, test.comment, v)
+		}
+		if d0 != f.String() {
+			t.Errorf("test %d expected:\n%q\ngot:\n%q", i, d0, f.String())
+		}
+
+		// set values and reload
+		f.SetKey("a.url", "http://y/#b")
+		f.SetKey("a.new", "#00ff00")
+		g, err := LoadString(f.String(), parser.InlineComments(test.policy))
+		if err != nil {
+			t.Fatalf("test %d could not load string: %v", i, err)
+		}
+		for _, key := range []string{"a.url", "a.new"} {
+			if v, exp := g.GetKey(key), f.GetKey(key); v != exp {
+				t.Errorf("test %d %s after reload expected %q, got: %q", i, key, exp, v)
+			}
+		}
+		if v := g.GetSection("a").KeyComment("url"); v != test.comment {
+			t.Errorf("test %d comment after reload expected %q, got: %q", i, test.comment, v)
+		}
+	}
+
+	// inline comments cannot be added when disabled
+	f, err := LoadString(d0, parser.InlineComments(parser.InlineCommentNone))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if err := f.GetSection("a").SetKeyComment("color", "c"); !errors.Is(err, parser.ErrInlineCommentsDisabled) {
+		t.Errorf("expected ErrInlineCommentsDisabled, got: %v", err)
+	}
+
+	// key-only entries with an inline comment can not be given a value
+	d1 := "[a]\nflag ; note\n"
+	f, err = LoadString(d1, parser.InlineComments(parser.InlineCommentNone))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if err := f.SetKeyErr("a.flag", "1"); !errors.Is(err, parser.ErrInlineCommentsDisabled) {
+		t.Errorf("expected ErrInlineCommentsDisabled, got: %v", err)
+	}
+	if err := f.ReplaceAll("a.flag", "1", ""); !errors.Is(err, parser.ErrInlineCommentsDisabled) {
+		t.Errorf("expected ErrInlineCommentsDisabled from ReplaceAll, got: %v", err)
+	}
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+	if err := f.GetSection("a").SetKeyComment("flag", ""); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := f.SetKeyErr("a.flag", "1"); err != nil || f.GetKey("a.flag") != "1" {
+		t.Errorf("expected flag to be set after removing the comment, got: %v", err)
+	}
+}
+
+func TestCaseModes(t *testing.T) {
+	d0 := "[Server]\nMaxConnections = 10\n"
+
+	// fold (default)
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	f.SetKey("server.NewKey", "x")
+	if exp := d0 + "newkey=x\n"; exp != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, f.String())
+	}
+
+	// preserve
+	f, err = LoadString(d0, parser.Case(parser.CasePreserve))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if v := f.GetKey("server.maxconnections"); v != "10" {
+		t.Errorf("expected 10, got: %q", v)
+	}
+	f.SetKey("SERVER.MAXCONNECTIONS", "20")
+	f.SetKey("server.NewKey", "x")
+	f.AddSection("Other")
+	if exp := "[Server]\nMaxConnections = 20\nNewKey=x\n[Other]\n"; exp != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, f.String())
+	}
+	if keys := f.GetSection("server").Keys(); !reflect.DeepEqual(keys, []string{"MaxConnections", "NewKey"}) {
+		t.Errorf("keys should preserve spelling, got: %v", keys)
+	}
+	if names := f.SectionNames(); !reflect.DeepEqual(names, []string{"", "Server", "Other"}) {
+		t.Errorf("section names should preserve spelling, got: %v", names)
+	}
+	var cfg struct {
+		Server struct {
+			MaxConnections int `ini:"maxconnections"`
+		} `ini:"server"`
+	}
+	if err := f.Decode(&cfg); err != nil || cfg.Server.MaxConnections != 20 {
+		t.Errorf("expected decoded 20, got: %d, %v", cfg.Server.MaxConnections, err)
+	}
+
+	// sensitive
+	f, err = LoadString(d0, parser.Case(parser.CaseSensitive))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if v := f.GetKey("server.maxconnections"); v != "" {
+		t.Errorf("expected no value, got: %q", v)
+	}
+	if v := f.GetKey("Server.MaxConnections"); v != "10" {
+		t.Errorf("expected 10, got: %q", v)
+	}
+	f.SetKey("Server.maxConnections", "1")
+	if exp := d0 + "maxConnections=1\n"; exp != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, f.String())
+	}
+
+	// switch mode on an existing file
+	g := NewFile()
+	g.SetCaseMode(parser.CasePreserve)
+	g.SetKey("A.B", "c")
+	if exp := "[A]\n\tB=c\n"; exp != g.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, g.String())
+	}
+}
+
+func TestIterators(t *testing.T) {
+	d0 := "top=1\n[b]\nx=1\nflag\nx=2\n[a]\ny = \"quoted\"\n[b]\nz=3\n[empty]\n"
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	var names []string
+	for s := range f.Sections() {
+		names = append(names, s.Name())
+	}
+	if exp := []string{"", "b", "a", "b", "empty"}; !reflect.DeepEqual(exp, names) {
+		t.Errorf("expected sections %v, got: %v", exp, names)
+	}
+
+	var kv []string
+	for k, v := range f.GetSection("b").All() {
+		kv = append(kv, k, v)
+	}
+	if exp := []string{"x", "1", "flag", parser.FlagValue, "x", "2"}; !reflect.DeepEqual(exp, kv) {
+		t.Errorf("expected %v, got: %v", exp, kv)
+	}
+
+	kv = nil
+	for k, v := range f.All() {
+		kv = append(kv, k, v)
+	}
+	exp := []string{"top", "1", "b.x", "1", "b.flag", parser.FlagValue, "b.x", "2", "a.y", "quoted", "b.z", "3"}
+	if !reflect.DeepEqual(exp, kv) {
+		t.Errorf("expected %v, got: %v", exp, kv)
+	}
+
+	// stop early
+	n := 0
+	for range f.All() {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected iteration to stop after 2, got: %d", n)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	f, err := LoadString("[B]\nx=1\nflag\nx=2\n[a]\n[b]\nz=3\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	snap := f.Snapshot()
+	exp := parser.Snapshot{
+		{Name: ""},
+		{Name: "b", Entries: []parser.Entry{{Key: "x", Value: "1"}, {Key: "flag", Flag: true}, {Key: "x", Value: "2"}}},
+		{Name: "a"},
+		{Name: "b", Entries: []parser.Entry{{Key: "z", Value: "3"}}},
+	}
+	if !reflect.DeepEqual(exp, snap) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", exp, snap)
+	}
+	if v, ok := snap[1].Get("x"); !ok || v != "1" {
+		t.Errorf("expected first value 1, got: %q, %t", v, ok)
+	}
+	if v, ok := snap[1].Get("flag"); !ok || v != parser.FlagValue {
+		t.Errorf("expected %q, got: %q, %t", parser.FlagValue, v, ok)
+	}
+	if _, ok := snap[1].Get("z"); ok {
+		t.Errorf("z should not be in first b section")
+	}
+	if n := len(snap.Sections("b")); n != 2 {
+		t.Errorf("expected 2 b sections, got: %d", n)
+	}
+
+	// snapshot is not changed by later edits
+	f.SetKey("a.new", "v")
+	if len(snap[2].Entries) != 0 {
+		t.Errorf("snapshot should not change, got: %+v", snap[2])
+	}
+}
+
+func TestIndex(t *testing.T) {
+	f, err := LoadString("a=1\n[s]\nk=1\nk=2\n[t]\nx=1\n[s]\nk=3\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	check := func(key, exp string) {
+		t.Helper()
+		if v := f.GetKey(key); v != exp {
+			t.Errorf("%s expected %q, got: %q", key, exp, v)
+		}
+	}
+	check("a", "1")
+	check("s.k", "1")
+	check("t.x", "1")
+
+	// add and remove keys
+	f.SetKey("s.new", "v")
+	check("s.new", "v")
+	f.GetSection("s").RemoveKey("k")
+	check("s.k", "2")
+	f.GetSection("s").RemoveKey("k")
+	check("s.k", "")
+	if err := f.GetSection("s").AddValue("k", "4"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	check("s.k", "4")
+	if err := f.GetSection("t").RenameKey("x", "y"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	check("t.x", "")
+	check("t.y", "1")
+	if err := f.GetSection("t").MoveKey("y", f.GetSection("s")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	check("t.y", "")
+	check("s.y", "1")
+
+	// add, rename and remove sections
+	f.SetKey("u.z", "1")
+	check("u.z", "1")
+	f.RenameSection("s", "v")
+	check("v.new", "v")
+	check("s.k", "3")
+	f.RenameSection("v", "s")
+	check("s.new", "v")
+	if n := len(f.GetSections("s")); n != 2 {
+		t.Errorf("expected 2 sections, got: %d", n)
+	}
+	f.RemoveSection("s")
+	check("s.new", "")
+	check("s.k", "3")
+	f.RemoveSection("s")
+	check("s.k", "")
+	check("u.z", "1")
+
+	// changing comparison funcs
+	f.SetKey("u.Z", "2")
+	f.SetCaseMode(parser.CaseSensitive)
+	f.SetKey("u.Z", "3")
+	check("u.z", "2")
+	check("u.Z", "3")
+	f.KeyCompFunc = func(a, b string) bool {
+		return true
+	}
+	check("u.anything", "2")
+	f.SetCaseMode(parser.CaseFold)
+	check("u.Z", "2")
+	if exp := "a=1\n[t]\n[u]\n\tz=2\n\tZ=3\n"; exp != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, f.String())
+	}
+
+	// closures created from the same function literal
+	nameFunc := func(prefix string) func(string) string {
+		return func(name string) string {
+			return strings.TrimPrefix(strings.TrimSpace(name), prefix)
+		}
+	}
+	f.SectionNameFunc = nameFunc("x")
+	f.RenameSection("u", "xu")
+	check("u.z", "2")
+	f.SectionNameFunc = nameFunc("y")
+	f.ResetIndex()
+	check("u.z", "")
+	check("xu.z", "2")
+
+	// the delimiter and comment separator for new keys and comments follow
+	// removed keys and comments
+	f, err = LoadString("# doc\n[s]\na: 1\nb = 2 ; c\nc = 3\n", parser.Delimiters("=:"))
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	s := f.GetSection("s")
+	s.SetKey("d", "4")
+	s.RemoveKey("b")
+	s.RemoveKey("c")
+	s.SetKey("e", "5")
+	s.SetDocComment("")
+	if err := s.SetKeyComment("e", "x"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := "[s]\na: 1\nd=4\ne:5 ; x\n"; exp != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", exp, f.String())
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	f, _, err := parser.Parse("", []byte(benchString(20)))
+	if err != nil {
+		t.Fatalf("could not parse: %v", err)
+	}
+
+	// the index is built by the first reader
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan string, 8*20)
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := 0; i < 20; i++ {
+				section := fmt.Sprintf("section%d", i)
+				if v := f.GetKey(section + ".key99"); v != "value99" {
+					errs <- fmt.Sprintf("%s.key99 expected %q, got: %q", section, "value99", v)
+				}
+				if s := f.GetSection(section); s == nil || s.Get("key0") != "value0" || !s.HasKey("key50") || s.DocComment() != "" || s.KeyDocComment("key2") != "" {
+					errs <- fmt.Sprintf("%s expected key0 and key50", section)
+				}
+				if v := f.GetAll(section + ".key1"); len(v) != 1 {
+					errs <- fmt.Sprintf("%s.key1 expected 1 value, got: %d", section, len(v))
+				}
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// chunkReader reads at most n bytes at a time from r.
+type chunkReader struct {
+	r io.Reader
+	n int
+}
+
+func (r chunkReader) Read(p []byte) (int, error) {
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	return r.r.Read(p)
+}
+
+// readers returns readers for s, reading all at once, one byte at a time, and
+// in small chunks.
+func readers(s string) []io.Reader {
+	v := []io.Reader{strings.NewReader(s), iotest.OneByteReader(strings.NewReader(s))}
+	for n := 2; n <= 16; n++ {
+		v = append(v, chunkReader{strings.NewReader(s), n})
+	}
+	return v
+}
+
+// scanEvents returns a description of each of the events and the error
+// scanned from r.
+func scanEvents(r io.Reader, opts ...parser.Option) []string {
+	s := parser.NewScanner(r, opts...)
+	var v []string
+	for s.Scan() {
+		v = append(v, fmt.Sprintf("%+v", s.Event()))
+	}
+	return append(v, fmt.Sprintf("%v", s.Err()))
+}
+
+func TestScanner(t *testing.T) {
+	tests := []struct {
+		s    string
+		opts []parser.Option
+	}{
+		{complexString, nil},
+		{"a = \"multi\nline\" ; c\r\n[s] ; sc\r\nb=\"\\u00e9\"\r\n\r\nflag", nil},
+		{"[s]\nk = a\n  b\n\n  c\nn = 1", []parser.Option{parser.Continuation(parser.IndentContinuation)}},
+		{"[s]\nk = a \\\n  b\nn: 1\n", []parser.Option{parser.Continuation(parser.BackslashContinuation), parser.Delimiters("=:")}},
+		{"[Mixed]\nKey=1\n", []parser.Option{parser.Case(parser.CasePreserve)}},
+		{"k=1\n[bad\n[s]\nk=2\n", []parser.Option{parser.Lenient(true)}},
+		{benchString(20), nil},
+		{"k=\"a\nb\\tc\"\nx=1\n", nil},
+		{"k = \"\\u00e9\\u00e9\\u00e9\" ; c\r\n// c\r\nx = \"a\nb\" // c\r\n", []parser.Option{parser.CommentPrefixes("//", ";")}},
+		{"[s]\nk = \"a \\\n  b\" \\\n  c\n", []parser.Option{parser.Continuation(parser.BackslashContinuation)}},
+		{"k=\"a\n[bad\n\"x\"y\n[s]\nk=\"\\u00e9\n", []parser.Option{parser.Lenient(true), parser.Continuation(parser.IndentContinuation)}},
+	}
+	for i, test := range tests {
+		f, _, err := parser.Parse("", []byte(strings.TrimRight(test.s, "\n")+"\n"), test.opts...)
+		if f == nil {
+			t.Fatalf("test %d could not parse: %v", i, err)
+		}
+		var exp []string
+		for _, section := range f.Snapshot() {
+			for _, e := range section.Entries {
+				exp = append(exp, fmt.Sprintf("%s|%s|%s|%t", section.Name, e.Key, e.Value, e.Flag))
+			}
+		}
+
+		for _, r := range readers(test.s) {
+			s := parser.NewScanner(r, test.opts...)
+			var keys []string
+			lines, text := 0, ""
+			for s.Scan() {
+				e := s.Event()
+				if e.Kind == parser.KeyEvent {
+					keys = append(keys, fmt.Sprintf("%s|%s|%s|%t", e.Section, e.Key, e.Value, e.Flag))
+				}
+				if e.Pos.Line <= lines {
+					t.Errorf("test %d expected line after %d, got: %d", i, lines, e.Pos.Line)
+				}
+				if src := test.s[e.Pos.Offset:]; !strings.HasPrefix(src, strings.TrimLeft(e.Text, " \t")) {
+					t.Errorf("test %d event at %v does not match text %q, got: %q", i, e.Pos, e.Text, src)
+				}
+				lines = e.Pos.Line + strings.Count(e.Text, "\n")
+				text += e.Text + "\n"
+			}
+			if err := s.Err(); err != nil {
+				t.Errorf("test %d expected no error, got: %v", i, err)
+			}
+			if !reflect.DeepEqual(exp, keys) {
+				t.Errorf("test %d expected:\n%q\ngot:\n%q", i, exp, keys)
+			}
+			if exp := strings.ReplaceAll(strings.TrimRight(test.s, "\n")+"\n", "\r\n", "\n"); exp != strings.ReplaceAll(text, "\r\n", "\n") {
+				t.Errorf("test %d expected text:\n%q\ngot:\n%q", i, exp, text)
+			}
+		}
+	}
+
+	// events
+	s := parser.NewScanner(strings.NewReader("; top\n\n[Sect] ; c\n  key = \"v\" ; kc\n"))
+	var events []parser.Event
+	for s.Scan() {
+		events = append(events, s.Event())
+	}
+	exp := []parser.Event{
+		{Kind: parser.CommentEvent, Pos: parser.Position{Line: 1, Col: 1, Offset: 0}, Comment: "top", Text: "; top"},
+		{Kind: parser.BlankEvent, Pos: parser.Position{Line: 2, Col: 1, Offset: 6}},
+		{Kind: parser.SectionEvent, Pos: parser.Position{Line: 3, Col: 1, Offset: 7}, Section: "sect", Comment: "c", Text: "[Sect] ; c"},
+		{Kind: parser.KeyEvent, Pos: parser.Position{Line: 4, Col: 3, Offset: 20}, Section: "sect", Key: "key", Value: "v", Comment: "kc", Text: "  key = \"v\" ; kc"},
+	}
+	if !reflect.DeepEqual(exp, events) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", exp, events)
+	}
+
+	// errors are reported at the same position as Parse
+	for _, d := range []string{
+		"k0=v0\n[sect1]\n\t[bad section\nk1=v1\n",
+		"k=\"a\n[s\n",
+		"k=v\n[s]\nx=\xff\n",
+		"x=\"\r\na\n]u\r\nb\\ c\":\n[\n",
+		"[s]\nk=\"\\u00e9\n]\n",
+		"k=\"a\\u00e9\" b\n",
+	} {
+		_, pos, perr := parser.Parse("", []byte(d))
+		for _, r := range readers(d) {
+			s := parser.NewScanner(r)
+			for s.Scan() {
+			}
+			var err *parser.Error
+			if !errors.As(s.Err(), &err) {
+				t.Fatalf("expected *parser.Error, got: %v", s.Err())
+			}
+			if err.Pos != pos || !errors.Is(err, errors.Unwrap(perr)) && !errors.Is(err, perr) {
+				t.Errorf("%q expected %v at %v, got: %v at %v", d, perr, pos, err, err.Pos)
+			}
+		}
+	}
+
+	// the events and errors do not depend on how the data is read
+	for _, opts := range [][]parser.Option{
+		nil,
+		{parser.Lenient(true)},
+		{parser.Continuation(parser.BackslashContinuation), parser.Lenient(true)},
+		{parser.Continuation(parser.IndentContinuation), parser.Lenient(true)},
+		{parser.Delimiters(":= "), parser.InlineComments(parser.InlineCommentWhitespace)},
+		{parser.CommentPrefixes("//"), parser.InlineComments(parser.InlineCommentNone), parser.Lenient(true)},
+	} {
+		for _, d := range []string{
+			"k=\"a\nb\\tc\"\nx=1\n",
+			"u=\"\\n :\\t=\n \\tx\t];//é\\//\r\n#k0\\n\\n\n",
+			"\t=]\n\"[ \"[a \n\\",
+			"s=\"\r\nkké\n\t=é\\u00e9u=:[#//u",
+			"é0\\n:\" \n:ux\\té\\ts\\tx]xa\n0;ék",
+			"k = a \\\r\n  \"b\\u00e9\" \\\n  [x\n",
+		} {
+			exp := scanEvents(strings.NewReader(d), opts...)
+			for j, r := range readers(d)[1:] {
+				if v := scanEvents(r, opts...); !reflect.DeepEqual(exp, v) {
+					t.Errorf("%q reader %d expected:\n%q\ngot:\n%q", d, j, exp, v)
+				}
+			}
+		}
+	}
+
+	// lenient
+	s = parser.NewScanner(strings.NewReader("k=1\n[bad\n"), parser.Lenient(true))
+	for s.Scan() {
+		if e := s.Event(); e.Kind == parser.InvalidEvent && (e.Text != "[bad" || e.Err == nil || e.Err.Pos.Line != 2) {
+			t.Errorf("unexpected invalid event: %+v", e)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	d0 := "k0=v0\n[a]\nk1=v1\n[b]\nk2=long value\n"
+	tests := []struct {
+		opt   parser.Option
+		limit parser.Limit
+		line  int
+		col   int
+	}{
+		{parser.MaxBytes(len(d0) - 1), parser.LimitBytes, 0, 0},
+		{parser.MaxLineLength(8), parser.LimitLineLength, 5, 9},
+		{parser.MaxSections(1), parser.LimitSections, 4, 1},
+		{parser.MaxKeys(2), parser.LimitKeys, 5, 1},
+	}
+	for i, test := range tests {
+		_, err := LoadString(d0, test.opt)
+		var le *parser.LimitError
+		if !errors.As(err, &le) || le.Limit != test.limit || !errors.Is(err, parser.ErrLimitExceeded) {
+			t.Errorf("test %d expected %v limit error, got: %v", i, test.limit, err)
+		}
+		var pe *ParseError
+		if test.line != 0 && (!errors.As(err, &pe) || pe.Line != test.line || pe.Column != test.col) {
+			t.Errorf("test %d expected error on line %d:%d, got: %v", i, test.line, test.col, err)
+		}
+
+		// scanner reports the error after the preceding lines
+		s := parser.NewScanner(iotest.OneByteReader(strings.NewReader(d0)), test.opt)
+		n := 0
+		for s.Scan() {
+			n++
+		}
+		var err2 *parser.Error
+		if !errors.As(s.Err(), &err2) || !errors.As(err2, &le) || le.Limit != test.limit {
+			t.Errorf("test %d expected scanner %v limit error, got: %v", i, test.limit, s.Err())
+		}
+		if test.line != 0 && (err2.Pos.Line != test.line || err2.Pos.Col != test.col || n != test.line-1) {
+			t.Errorf("test %d expected scanner error on line %d:%d after %d events, got: %v after %d events", i, test.line, test.col, test.line-1, err2.Pos, n)
+		}
+	}
+
+	// limits not exceeded
+	opts := []parser.Option{parser.MaxBytes(len(d0)), parser.MaxLineLength(14), parser.MaxSections(2), parser.MaxKeys(3)}
+	if _, err := LoadString(d0, opts...); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if _, err := LoadString(strings.TrimSuffix(d0, "\n"), parser.MaxBytes(len(d0)-1)); err != nil {
+		t.Errorf("expected no error without final line ending, got: %v", err)
+	}
+
+	// reading stops at the size limit
+	r := strings.NewReader(strings.Repeat("k=v\n", 1<<16))
+	if _, err := Load(r, parser.MaxBytes(100)); !errors.Is(err, parser.ErrLimitExceeded) {
+		t.Errorf("expected limit error, got: %v", err)
+	}
+	if n := r.Len(); n < 4<<16-101 {
+		t.Errorf("expected no more than 101 bytes to be read, read: %d", 4<<16-n)
+	}
+
+	// invalid utf-8
+	d1 := "k=\xff\n"
+	if _, err := LoadString(d1); !errors.Is(err, parser.ErrInvalidEncoding) {
+		t.Errorf("expected ErrInvalidEncoding, got: %v", err)
+	}
+	f, err := LoadString(d1, parser.AllowInvalidUTF8(true))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := f.GetKey("k"); v != "\xff" {
+		t.Errorf("expected %q, got: %q", "\xff", v)
+	}
+	if d1 != f.String() {
+		t.Errorf("expected %q, got: %q", d1, f.String())
+	}
+	s := parser.NewScanner(strings.NewReader(d1), parser.AllowInvalidUTF8(true))
+	if !s.Scan() || s.Event().Value != "\xff" {
+		t.Errorf("expected %q, got: %q (%v)", "\xff", s.Event().Value, s.Err())
+	}
+}
+
+// benchString returns a generated file with n sections of 100 keys.
+func benchString(n int) string {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "[section%d]\n", i)
+		for j := 0; j < 100; j++ {
+			fmt.Fprintf(&buf, "key%d = value%d\n", j, j)
+		}
+	}
+	return buf.String()
+}
+
+func BenchmarkGetKey(b *testing.B) {
+	f, err := LoadString(benchString(200))
+	if err != nil {
+		b.Fatalf("could not load string: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if v := f.GetKey(fmt.Sprintf("section%d.key%d", i%200, i%100)); v == "" {
+			b.Fatalf("expected value for %d", i)
+		}
+	}
+}
+
+func BenchmarkSetKey(b *testing.B) {
+	f, err := LoadString(benchString(200))
+	if err != nil {
+		b.Fatalf("could not load string: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.SetKey(fmt.Sprintf("section%d.key%d", i%200, i%100), "v")
+	}
+}
+
+func BenchmarkInsertKey(b *testing.B) {
+	f, err := LoadString(benchString(200))
+	if err != nil {
+		b.Fatalf("could not load string: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.SetKey(fmt.Sprintf("section%d.new%d", i%200, i), "v")
+	}
+}
+
+func BenchmarkRemoveKey(b *testing.B) {
+	var f *File
+	for i := 0; i < b.N; i++ {
+		if i%20000 == 0 {
+			b.StopTimer()
+			var err error
+			if f, err = LoadString(benchString(200)); err != nil {
+				b.Fatalf("could not load string: %v", err)
+			}
+			b.StartTimer()
+		}
+		f.RemoveKey(fmt.Sprintf("section%d.key%d", i%200, i/200%100))
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	f, err := LoadString(benchString(200))
+	if err != nil {
+		b.Fatalf("could not load string: %v", err)
+	}
+	b.ResetTimer()
+	type section struct {
+		First string `ini:"key0"`
+		Last  string `ini:"key99"`
+	}
+	for i := 0; i < b.N; i++ {
+		var v struct {
+			A section `ini:"section0"`
+			B section `ini:"section100"`
+			C section `ini:"section199"`
+		}
+		if err := f.Decode(&v); err != nil || v.C.Last != "value99" {
+			b.Fatalf("expected no error, got: %v", err)
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	tests := []struct {
+		name string
+		s    string
+	}{
+		{"complex", complexString + "\n"},
+		{"large", benchString(200)},
+	}
+	for _, test := range tests {
+		b.Run(test.name, func(b *testing.B) {
+			b.SetBytes(int64(len(test.s)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s := parser.NewScanner(strings.NewReader(test.s))
+				for s.Scan() {
+				}
+				if err := s.Err(); err != nil {
+					b.Fatalf("expected no error, got: %v", err)
+				}
+			}
+		})
+	}
+}
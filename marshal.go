@@ -0,0 +1,581 @@
+package ini
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenshaw/ini/parser"
+)
+
+// sliceSeparator is the separator used for slice values.
+const sliceSeparator = ","
+
+// UnmarshalTypeError is a ini value that could not be converted to a Go
+// type.
+type UnmarshalTypeError struct {
+	Key   string       // key name in form of section.key
+	Value string       // ini value
+	Type  reflect.Type // type of Go value it could not be assigned to
+	Err   error        // underlying conversion error (if any)
+}
+
+// Error satisfies the error interface.
+func (err *UnmarshalTypeError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("cannot unmarshal %q into %s (key %s): %v", err.Value, err.Type, err.Key, err.Err)
+	}
+	return fmt.Sprintf("cannot unmarshal %q into %s (key %s)", err.Value, err.Type, err.Key)
+}
+
+// Unwrap returns the underlying conversion error.
+func (err *UnmarshalTypeError) Unwrap() error {
+	return err.Err
+}
+
+// Unmarshal parses the ini data and stores the section and key values in the
+// struct pointed to by v.
+//
+// See File.Decode for information on how sections and keys are mapped to
+// struct fields.
+func Unmarshal(data []byte, v interface{}) error {
+	f, err := LoadBytes(data)
+	if err != nil {
+		return err
+	}
+	return f.Decode(v)
+}
+
+// Marshal returns the ini encoding of v.
+//
+// See File.Encode for information on how struct fields are mapped to sections
+// and keys.
+func Marshal(v interface{}) ([]byte, error) {
+	f := NewFile()
+	if err := f.Encode(v); err != nil {
+		return nil, err
+	}
+	return []byte(f.String()), nil
+}
+
+// Decode stores the File's section and key values in the struct pointed to
+// by v.
+//
+// Exported fields of v that are structs (or pointers to structs) are mapped
+// to the Section of the same name, and all other exported fields are mapped
+// to keys in the empty (first) Section. Fields of nested structs are mapped to
+// the Section named in the form of section.subsection. Embedded structs
+// without a name are treated as if their fields were part of the parent
+// struct.
+//
+// Section and key names are taken from the field's `ini:"name"` struct tag,
+// falling back to the field name, and are compared using the File's
+// SectionCompFunc/SectionNameFunc and KeyCompFunc. A field with the tag
+// `ini:"-"` is skipped.
+//
+// Fields that are slices of structs (or pointers to structs) are mapped to
+// all sections of the same name, such as repeated [Peer] sections, with an
+// element for each section. Fields of nested structs within the elements are
+// mapped to the first section of the form section.subsection.
+//
+// Values are converted to strings, bools, ints, uints, floats,
+// time.Duration, url.URL, types implementing encoding.TextUnmarshaler, and
+// slices of those (separated by commas). Key-only entries (ie, bare flags)
+// are decoded as parser.FlagValue. Sections and keys not present in the File are
+// left unmodified in v.
+func (f *File) Decode(v interface{}) error {
+	return f.decode(v, false)
+}
+
+// decode stores the File's section and key values in the struct pointed to
+// by v. When strict is true, an error is returned for any key in the File
+// that is not mapped to a field in v.
+func (f *File) decode(v interface{}, strict bool) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPointer
+	}
+
+	used := make(map[*parser.Section]map[string]bool)
+	if err := f.decodeSection(used, f.GetSection(""), "", rv.Elem(), true); err != nil {
+		return err
+	}
+	if !strict {
+		return nil
+	}
+
+	// check for keys not mapped to a field
+	for _, section := range f.AllSections() {
+		for _, k := range section.RawKeys() {
+			if used[section][k] {
+				continue
+			}
+			key := f.KeyManipFunc(k)
+			if name := section.Name(); name != "" {
+				key = name + parser.DefaultNameKeySeparator + key
+			}
+			return fmt.Errorf("%s: %w", key, ErrUnknownKey)
+		}
+	}
+	return nil
+}
+
+// decodeSection decodes the keys of section into the struct rv, recursing
+// into any struct fields as subsections. Decoded keys are recorded in used.
+func (f *File) decodeSection(used map[*parser.Section]map[string]bool, section *parser.Section, name string, rv reflect.Value, top bool) error {
+	for _, fld := range structFields(rv, true) {
+		if isSectionSliceType(fld.v.Type()) {
+			n := fld.name
+			if !top {
+				n = name + parser.DefaultNameKeySeparator + n
+			}
+			sections := f.GetSections(n)
+			if len(sections) == 0 {
+				continue
+			}
+			v := reflect.MakeSlice(fld.v.Type(), len(sections), len(sections))
+			for i, s := range sections {
+				if err := f.decodeSection(used, s, n, indirect(v.Index(i)), false); err != nil {
+					return err
+				}
+			}
+			fld.v.Set(v)
+			continue
+		}
+
+		if isSectionType(fld.v.Type()) {
+			n := fld.name
+			if !top {
+				n = name + parser.DefaultNameKeySeparator + n
+			}
+			// only allocate pointers when section is present
+			s := f.GetSection(n)
+			if s == nil && fld.v.Kind() == reflect.Ptr {
+				continue
+			}
+			if err := f.decodeSection(used, s, n, indirect(fld.v), false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if section == nil {
+			continue
+		}
+		keys := f.lookupKeys(section, fld.name)
+		if len(keys) == 0 {
+			continue
+		}
+		if used[section] == nil {
+			used[section] = make(map[string]bool)
+		}
+		for _, k := range keys {
+			used[section][k] = true
+		}
+
+		val := section.Get(fld.name)
+		if !section.HasValue(fld.name) {
+			val = parser.FlagValue
+		}
+		if err := unmarshalValue(fld.v, val); err != nil {
+			key := fld.name
+			if name != "" {
+				key = name + parser.DefaultNameKeySeparator + key
+			}
+			return &UnmarshalTypeError{
+				Key:   key,
+				Value: val,
+				Type:  fld.v.Type(),
+				Err:   err,
+			}
+		}
+	}
+	return nil
+}
+
+// Encode sets the File's section and key values from the struct (or pointer
+// to struct) v.
+//
+// Keys are written using Section.SetKeyErr, so existing lines, comments and
+// spacing in the File are preserved. Struct fields are mapped to sections and
+// keys in the same way as File.Decode. Fields with the `ini:",omitempty"`
+// tag option are not written when they are the zero value, and nil pointers
+// are never written.
+//
+// Elements of slices of structs are written to the existing sections of the
+// same name in order, appending sections as needed, and any remaining
+// sections of the same name are removed. Nil slices of structs are not
+// written.
+func (f *File) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+	return f.encodeSection("", nil, rv, true)
+}
+
+// encodeSection sets the keys of section name from the struct rv, recursing
+// into any struct fields as subsections. If section is nil, then the section
+// is retrieved by name (or created) when the first key is set.
+func (f *File) encodeSection(name string, section *parser.Section, rv reflect.Value, top bool) error {
+	for _, fld := range structFields(rv, false) {
+		if fld.v.Kind() == reflect.Ptr && fld.v.IsNil() {
+			continue
+		}
+
+		if isSectionSliceType(fld.v.Type()) {
+			if fld.v.IsNil() {
+				continue
+			}
+			n := fld.name
+			if !top {
+				n = name + parser.DefaultNameKeySeparator + n
+			}
+			if err := f.encodeSections(n, fld.v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isSectionType(fld.v.Type()) {
+			n := fld.name
+			if !top {
+				n = name + parser.DefaultNameKeySeparator + n
+			}
+			if err := f.encodeSection(n, nil, indirect(fld.v), false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fld.omitEmpty && isEmptyValue(fld.v) {
+			continue
+		}
+		val, err := marshalValue(fld.v)
+		if err != nil {
+			return err
+		}
+
+		// create section on first key
+		if section == nil {
+			if section = f.GetSection(name); section == nil {
+				if section, err = f.AddSectionErr(name); err != nil {
+					return err
+				}
+			}
+		}
+		if err := section.SetKeyErr(fld.name, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSections sets the keys of the sections named name from the elements
+// of the slice rv, appending or removing sections so that there is a section
+// for each element.
+func (f *File) encodeSections(name string, rv reflect.Value) error {
+	sections := f.GetSections(name)
+	n := 0
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+
+		// use existing section, or append
+		var section *parser.Section
+		if n < len(sections) {
+			section = sections[n]
+		} else {
+			var err error
+			if section, err = f.AppendSection(name); err != nil {
+				return err
+			}
+		}
+		if err := f.encodeSection(name, section, v, false); err != nil {
+			return err
+		}
+		n++
+	}
+
+	// remove remaining sections
+	for i := len(sections) - 1; i >= n; i-- {
+		if err := f.RemoveSectionAt(name, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupKeys returns the raw key names in section matching key, which may
+// differ in spelling (ie, in case).
+func (f *File) lookupKeys(section *parser.Section, key string) []string {
+	var keys []string
+	for _, k := range section.RawKeys() {
+		if f.KeyCompFunc(k, key) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// field is a struct field with its ini name and options.
+type field struct {
+	name      string
+	omitEmpty bool
+	v         reflect.Value
+}
+
+// structFields returns the exported ini fields of the struct rv, descending
+// into embedded structs. Nil embedded struct pointers are allocated when alloc
+// is true (ie, when decoding), and are otherwise skipped.
+func structFields(rv reflect.Value, alloc bool) []field {
+	var fields []field
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("ini")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i != -1 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+
+		// embedded struct without a name
+		if f.Anonymous && name == "" {
+			t := f.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Struct {
+				v := rv.Field(i)
+				if v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						if !alloc || !v.CanSet() {
+							continue
+						}
+						v.Set(reflect.New(t))
+					}
+					v = v.Elem()
+				}
+				fields = append(fields, structFields(v, alloc)...)
+				continue
+			}
+		}
+
+		// skip unexported
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			omitEmpty: opts == "omitempty",
+			v:         rv.Field(i),
+		})
+	}
+	return fields
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isSectionType determines if typ is mapped to a Section.
+func isSectionType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != urlType &&
+		!typ.Implements(textUnmarshalerType) &&
+		!reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// isSectionSliceType determines if typ is a slice mapped to repeated
+// sections.
+func isSectionSliceType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && isSectionType(typ.Elem())
+}
+
+// indirect allocates and dereferences pointers in rv.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// unmarshalValue converts s and stores it in rv.
+func unmarshalValue(rv reflect.Value, s string) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return unmarshalValue(rv.Elem(), s)
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch rv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(n)
+
+	case reflect.Slice:
+		if s == "" {
+			rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+			return nil
+		}
+		vals := strings.Split(s, sliceSeparator)
+		sl := reflect.MakeSlice(rv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := unmarshalValue(sl.Index(i), strings.TrimSpace(val)); err != nil {
+				return err
+			}
+		}
+		rv.Set(sl)
+
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// marshalValue converts rv to its string representation.
+func marshalValue(rv reflect.Value) (string, error) {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", nil
+		}
+		return marshalValue(rv.Elem())
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		buf, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(buf), err
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(textMarshalerType) {
+		buf, err := rv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(buf), err
+	}
+	switch rv.Type() {
+	case durationType:
+		return time.Duration(rv.Int()).String(), nil
+	case urlType:
+		u := rv.Interface().(url.URL)
+		return u.String(), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+
+	case reflect.Slice:
+		vals := make([]string, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			s, err := marshalValue(rv.Index(i))
+			if err != nil {
+				return "", err
+			}
+			vals[i] = s
+		}
+		return strings.Join(vals, sliceSeparator), nil
+	}
+	return "", ErrUnsupportedType
+}
+
+// isEmptyValue determines if rv is the zero value for its type.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
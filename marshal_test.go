@@ -0,0 +1,266 @@
+package ini
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name    string
+	Debug   bool   `ini:"debug"`
+	Ignored string `ini:"-"`
+
+	Server struct {
+		Host    net.IP        `ini:"host"`
+		Port    int           `ini:"port"`
+		Timeout time.Duration `ini:"timeout"`
+		Ratio   float64       `ini:"ratio,omitempty"`
+		Tags    []string      `ini:"tags"`
+
+		TLS *struct {
+			Cert string `ini:"cert"`
+		} `ini:"tls"`
+	} `ini:"server"`
+
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Users []uint `ini:"users"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := `name = test ; the name
+debug = true
+users = 1, 2, 3
+
+[server]
+host = 127.0.0.1
+port = 8080
+timeout = 1m30s
+tags = a,b , c
+
+[server.tls]
+cert = /path/to/cert
+`
+
+	var cfg testConfig
+	cfg.Ignored = "ignored"
+	if err := Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+
+	if cfg.Name != "test" {
+		t.Errorf("name should be test, got: %q", cfg.Name)
+	}
+	if !cfg.Debug {
+		t.Error("debug should be true")
+	}
+	if cfg.Ignored != "ignored" {
+		t.Errorf("ignored should not be modified, got: %q", cfg.Ignored)
+	}
+	if !reflect.DeepEqual(cfg.Users, []uint{1, 2, 3}) {
+		t.Errorf("users should be [1 2 3], got: %v", cfg.Users)
+	}
+	if !cfg.Server.Host.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("server.host should be 127.0.0.1, got: %v", cfg.Server.Host)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("server.port should be 8080, got: %d", cfg.Server.Port)
+	}
+	if cfg.Server.Timeout != 90*time.Second {
+		t.Errorf("server.timeout should be 1m30s, got: %v", cfg.Server.Timeout)
+	}
+	if !reflect.DeepEqual(cfg.Server.Tags, []string{"a", "b", "c"}) {
+		t.Errorf("server.tags should be [a b c], got: %v", cfg.Server.Tags)
+	}
+	if cfg.Server.TLS == nil || cfg.Server.TLS.Cert != "/path/to/cert" {
+		t.Errorf("server.tls.cert should be /path/to/cert, got: %v", cfg.Server.TLS)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var cfg testConfig
+	if err := Unmarshal([]byte("debug = yes\n"), cfg); err != ErrNotStructPointer {
+		t.Errorf("expected ErrNotStructPointer, got: %v", err)
+	}
+
+	err := Unmarshal([]byte("[server]\nport = eighty\n"), &cfg)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected *UnmarshalTypeError, got: %v", err)
+	}
+	if typeErr.Key != "server.port" || typeErr.Value != "eighty" {
+		t.Errorf("expected error for server.port=eighty, got: %v", typeErr)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	var cfg testConfig
+	cfg.Name = "test"
+	cfg.Users = []uint{1, 2}
+	cfg.Server.Host = net.IPv4(10, 0, 0, 1)
+	cfg.Server.Port = 80
+	cfg.Server.Timeout = 5 * time.Second
+
+	buf, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+
+	d0 := "name=test\ndebug=false\nusers=1,2\n[server]\n\thost=10.0.0.1\n\tport=80\n\ttimeout=5s\n\ttags=\n"
+	if d0 != string(buf) {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, string(buf))
+	}
+
+	var out testConfig
+	if err := Unmarshal(buf, &out); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if out.Name != cfg.Name || out.Server.Port != cfg.Server.Port || out.Server.Timeout != cfg.Server.Timeout {
+		t.Errorf("round trip should preserve values, got: %+v", out)
+	}
+}
+
+type TestEmbeddedPtr struct {
+	Level int `ini:"level"`
+}
+
+func TestEmbeddedPointer(t *testing.T) {
+	type config struct {
+		Name string `ini:"name"`
+		*TestEmbeddedPtr
+	}
+
+	// encoding does not allocate nil embedded structs
+	cfg := config{Name: "test"}
+	buf, err := Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	if cfg.TestEmbeddedPtr != nil {
+		t.Error("marshal should not modify the value")
+	}
+	if d0 := "name=test\n"; d0 != string(buf) {
+		t.Errorf("expected %q, got: %q", d0, string(buf))
+	}
+
+	// decoding allocates nil embedded structs
+	if err := Unmarshal([]byte("name = x\nlevel = 08\n"), &cfg); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if cfg.TestEmbeddedPtr == nil || cfg.Level != 8 {
+		t.Errorf("level should be 8, got: %+v", cfg.TestEmbeddedPtr)
+	}
+}
+
+func TestUnmarshalIntBase(t *testing.T) {
+	var v struct {
+		A int  `ini:"a"`
+		B uint `ini:"b"`
+	}
+	if err := Unmarshal([]byte("a = 010\nb = 09\n"), &v); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if v.A != 10 || v.B != 9 {
+		t.Errorf("expected base 10 values 10 and 9, got: %d, %d", v.A, v.B)
+	}
+	var typeErr *UnmarshalTypeError
+	if err := Unmarshal([]byte("a = 0x10\n"), &v); !errors.As(err, &typeErr) {
+		t.Errorf("expected *UnmarshalTypeError for 0x10, got: %v", err)
+	}
+}
+
+func TestEncodePreservesComments(t *testing.T) {
+	d0 := "; top comment\nname = old ; name comment\n\n[server] ; server comment\n  port = 1\n"
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	var cfg struct {
+		Name   string `ini:"name"`
+		Server struct {
+			Port int    `ini:"port"`
+			Host string `ini:"host"`
+		} `ini:"server"`
+	}
+	cfg.Name = "new"
+	cfg.Server.Port = 2
+	cfg.Server.Host = "localhost"
+	if err := f.Encode(&cfg); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+
+	d1 := "; top comment\nname = new; name comment\n\n[server] ; server comment\n  port = 2\n  host=localhost\n"
+	if d1 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, f.String())
+	}
+}
+
+func TestRepeatedSections(t *testing.T) {
+	data := `[Interface]
+PrivateKey = key0
+
+[Peer]
+PublicKey = key1
+AllowedIPs = 10.0.0.1/32
+
+[Peer]
+PublicKey = key2
+AllowedIPs = 10.0.0.2/32, 10.0.0.3/32
+`
+
+	type peer struct {
+		PublicKey  string
+		AllowedIPs []string
+	}
+	var cfg struct {
+		Interface struct {
+			PrivateKey string
+		}
+		Peer []*peer
+	}
+	f, err := LoadString(data)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if err := f.Decode(&cfg); err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	exp := []*peer{
+		{PublicKey: "key1", AllowedIPs: []string{"10.0.0.1/32"}},
+		{PublicKey: "key2", AllowedIPs: []string{"10.0.0.2/32", "10.0.0.3/32"}},
+	}
+	if !reflect.DeepEqual(cfg.Peer, exp) {
+		t.Errorf("expected %+v, got: %+v", exp, cfg.Peer)
+	}
+
+	// encode with one fewer peer
+	cfg.Peer = cfg.Peer[1:]
+	cfg.Peer[0].PublicKey = "key3"
+	if err := f.Encode(&cfg); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+	d0 := "[Interface]\nPrivateKey = key0\n\n[Peer]\nPublicKey = key3\nAllowedIPs = 10.0.0.2/32,10.0.0.3/32\n\n"
+	if d0 != f.String() {
+		t.Errorf("expected:\n%q\ngot:\n%q", d0, f.String())
+	}
+
+	// marshal appends sections
+	buf, err := Marshal(struct {
+		Peer []peer
+	}{
+		Peer: []peer{{PublicKey: "a"}, {PublicKey: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	d1 := "[peer]\n\tpublickey=a\n\tallowedips=\n[peer]\n\tpublickey=b\n\tallowedips=\n"
+	if d1 != string(buf) {
+		t.Errorf("expected:\n%q\ngot:\n%q", d1, string(buf))
+	}
+}
@@ -56,3 +56,17 @@ func GitSectionNameFunc(name string) string {
 	// replace any space or tab with .
 	return spaceOrTabRE.ReplaceAllString(n, parser.DefaultNameKeySeparator)
 }
+
+// Dialect configures a File for a specific flavor of ini file.
+//
+// Use it with Decoder.SetDialect and Encoder.SetDialect.
+type Dialect func(*File)
+
+// GitDialect is a Dialect that configures a File to manipulate section names
+// in a Gitconfig compatible way, using GitSectionManipFunc and
+// GitSectionNameFunc.
+func GitDialect(f *File) {
+	f.SectionManipFunc = GitSectionManipFunc
+	f.SectionNameFunc = GitSectionNameFunc
+	f.ResetIndex()
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CaseMode is the handling of letter case in section names and keys.
+type CaseMode int
+
+// CaseMode values.
+const (
+	// CaseFold lowercases section names and keys when they are created or
+	// altered, and compares them case-insensitively. This is the default.
+	CaseFold CaseMode = iota
+
+	// CasePreserve compares section names and keys case-insensitively, but
+	// preserves the spelling of existing names and uses the caller's spelling
+	// for new names.
+	CasePreserve
+
+	// CaseSensitive compares section names and keys case-sensitively, and
+	// preserves their spelling.
+	CaseSensitive
+)
+
+// Case creates an Option to set the case mode of the parsed File. See
+// File.SetCaseMode.
+func Case(mode CaseMode) Option {
+	return func(cfg *config) {
+		cfg.caseMode = mode
+	}
+}
+
+// SetCaseMode sets the File's section and key manipulation and comparison
+// funcs for the case mode.
+func (f *File) SetCaseMode(mode CaseMode) {
+	switch mode {
+	case CasePreserve:
+		f.SectionManipFunc, f.SectionNameFunc = strings.TrimSpace, strings.TrimSpace
+		f.SectionCompFunc = foldComp
+		f.KeyManipFunc, f.KeyCompFunc = strings.TrimSpace, foldComp
+	case CaseSensitive:
+		f.SectionManipFunc, f.SectionNameFunc = strings.TrimSpace, strings.TrimSpace
+		f.SectionCompFunc = exactComp
+		f.KeyManipFunc, f.KeyCompFunc = strings.TrimSpace, exactComp
+	default:
+		f.SectionManipFunc, f.SectionNameFunc = SectionManipFunc, SectionNameFunc
+		f.SectionCompFunc = nil
+		f.KeyManipFunc, f.KeyCompFunc = KeyManipFunc, KeyCompFunc
+	}
+	f.ResetIndex()
+}
+
+// foldComp compares names a, b case-insensitively, ignoring leading and
+// trailing whitespace.
+func foldComp(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// foldName returns name with leading and trailing whitespace removed, and
+// each rune replaced with the smallest rune it is equal to under simple case
+// folding, so that foldComp(a, b) when foldName(a) == foldName(b).
+func foldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return min
+	}, strings.TrimSpace(name))
+}
+
+// exactComp compares names a, b, ignoring leading and trailing whitespace.
+func exactComp(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
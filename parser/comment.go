@@ -0,0 +1,309 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Comment errors.
+var (
+	// ErrInvalidComment is the error returned when setting an inline comment
+	// containing a line ending.
+	ErrInvalidComment = errors.New("invalid comment")
+
+	// ErrInlineCommentsDisabled is the error returned when setting an inline
+	// comment for a key in a File parsed with InlineCommentNone.
+	ErrInlineCommentsDisabled = errors.New("inline comments disabled")
+)
+
+// isCommentPrefix determines if s is one of prefixes.
+func isCommentPrefix(prefixes []string, s string) bool {
+	for _, prefix := range prefixes {
+		if s == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCommentPrefix determines if s contains any of the File's comment
+// prefixes.
+func (f *File) containsCommentPrefix(s string) bool {
+	for _, prefix := range f.CommentPrefixes {
+		if strings.Contains(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text returns the comment text, without the comment separator and the
+// single space following it.
+func (c *Comment) Text() string {
+	return strings.TrimPrefix(strings.TrimRight(c.comment, " \t"), " ")
+}
+
+// newComment creates a comment for text using the File's comment separator.
+func (f *File) newComment(text string) *Comment {
+	if text != "" {
+		text = " " + text
+	}
+	cs := f.commentSeparator()
+	if f.idx != nil && f.idx.hasCS && f.idx.cs == "" {
+		// the new comment is the File's first comment
+		f.idx.cs = cs
+	}
+	return NewComment(position{}, cs, text)
+}
+
+// commentSeparator returns the comment separator for new comments, which is
+// the separator of the first comment in the File, or DefaultCommentSeparator
+// if there are no comments. If DefaultCommentSeparator is not one of the
+// File's comment prefixes, then the first prefix is used instead.
+func (f *File) commentSeparator() string {
+	idx := f.index()
+	if !idx.hasCS {
+		idx.cs, idx.hasCS = f.firstCommentSeparator(), true
+	}
+	if idx.cs != "" {
+		return idx.cs
+	}
+	if len(f.CommentPrefixes) != 0 && !isCommentPrefix(f.CommentPrefixes, DefaultCommentSeparator) {
+		return f.CommentPrefixes[0]
+	}
+	return DefaultCommentSeparator
+}
+
+// firstCommentSeparator returns the separator of the first comment in the
+// File, or "" if there are no comments.
+func (f *File) firstCommentSeparator() string {
+	for _, l := range f.lines {
+		var c *Comment
+		switch v := l.item.(type) {
+		case *Comment:
+			c = v
+		case *Section:
+			c = v.comment
+		case *KeyValuePair:
+			c = v.comment
+		}
+		if c != nil {
+			return c.cs
+		}
+	}
+	return ""
+}
+
+// separateComment separates the key's value from its inline comment with a
+// space, when required by the File's inline comment policy.
+func (f *File) separateComment(kvp *KeyValuePair) {
+	if kvp.comment == nil || kvp.value == nil || f.InlineComments != InlineCommentWhitespace {
+		return
+	}
+	if v := kvp.tail(); !strings.HasSuffix(*v, " ") && !strings.HasSuffix(*v, "\t") {
+		*v += " "
+	}
+}
+
+// docStart returns the index of the first line of the doc comment block for
+// the item on line idx, which are the comment lines directly preceding it.
+//
+// Returns idx if the item has no doc comment.
+func (f *File) docStart(idx int) int {
+	start := idx
+	for start > 0 {
+		if _, ok := f.lines[start-1].item.(*Comment); !ok {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// docComment returns the doc comment text for the item on line idx.
+func (f *File) docComment(idx int) string {
+	var lines []string
+	for _, l := range f.lines[f.docStart(idx):idx] {
+		lines = append(lines, l.item.(*Comment).Text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setDocComment replaces the doc comment block for the item on line idx with
+// a comment line for each line of text, returning the new index of the item.
+func (f *File) setDocComment(idx int, text string) int {
+	start := f.docStart(idx)
+	item := f.lines[idx]
+
+	// create lines
+	var lines []*Line
+	if text != "" {
+		for _, s := range strings.Split(text, "\n") {
+			c := f.newComment(strings.TrimSuffix(s, "\r"))
+			lines = append(lines, NewLine(position{}, item.ws, c, item.le))
+		}
+	}
+
+	// replace existing block
+	if start != idx {
+		f.resetCommentSeparator()
+	}
+	f.lines = append(f.lines[:start], append(lines, f.lines[idx:]...)...)
+	return start + len(lines)
+}
+
+// validComment checks that comment does not contain any line endings.
+func validComment(comment string) error {
+	if strings.ContainsAny(comment, "\r\n") {
+		return fmt.Errorf("%q: %w", comment, ErrInvalidComment)
+	}
+	return nil
+}
+
+// lineIndex returns the index of the Section's header line, or -1 if the
+// Section does not have a header line (ie, the empty section).
+func (s *Section) lineIndex() int {
+	return s.file.itemLine(s)
+}
+
+// Comment returns the Section's inline comment text, found on the same line
+// as the Section header.
+func (s *Section) Comment() string {
+	if s.comment == nil {
+		return ""
+	}
+	return s.comment.Text()
+}
+
+// SetComment sets the Section's inline comment text, using the comment
+// separator of the File's existing comments. An empty comment removes the
+// inline comment.
+//
+// Returns ErrInvalidComment if the comment contains a line ending. Has no
+// effect on the empty (first) section, as it does not have a header line.
+func (s *Section) SetComment(comment string) error {
+	if err := validComment(comment); err != nil {
+		return err
+	}
+	if s.lineIndex() < 0 {
+		return nil
+	}
+
+	switch {
+	case comment == "":
+		s.ws, s.comment = "", nil
+		s.file.resetCommentSeparator()
+	case s.comment != nil:
+		s.comment.comment = " " + comment
+	default:
+		if s.ws == "" {
+			s.ws = " "
+		}
+		s.comment = s.file.newComment(comment)
+	}
+	return nil
+}
+
+// DocComment returns the text of the Section's doc comment, which is the block
+// of comment lines directly above the Section header. Multiple lines are
+// separated by '\n'.
+func (s *Section) DocComment() string {
+	idx := s.lineIndex()
+	if idx < 0 {
+		return ""
+	}
+	return s.file.docComment(idx)
+}
+
+// SetDocComment replaces the Section's doc comment with a comment line for
+// each line of text, using the comment separator of the File's existing
+// comments. An empty text removes the doc comment.
+//
+// Has no effect on the empty (first) section, as it does not have a header
+// line.
+func (s *Section) SetDocComment(text string) {
+	if idx := s.lineIndex(); idx >= 0 {
+		s.file.setDocComment(idx, text)
+	}
+}
+
+// KeyComment returns the inline comment text for key, found on the same line
+// as the key.
+func (s *Section) KeyComment(key string) string {
+	k := s.key(key)
+	if k == nil || k.comment == nil {
+		return ""
+	}
+	return k.comment.Text()
+}
+
+// SetKeyComment sets the inline comment text for key, using the comment
+// separator of the File's existing comments. An empty comment removes the
+// inline comment.
+//
+// Returns ErrKeyNotFound if key is not defined, ErrInvalidComment if the
+// comment contains a line ending, or ErrInlineCommentsDisabled if the File was
+// parsed with InlineCommentNone.
+func (s *Section) SetKeyComment(key, comment string) error {
+	if err := validComment(comment); err != nil {
+		return err
+	}
+	if comment != "" && s.file.InlineComments == InlineCommentNone {
+		return fmt.Errorf("%s: %w", key, ErrInlineCommentsDisabled)
+	}
+	k := s.key(key)
+	if k == nil {
+		return fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+
+	switch {
+	case comment == "" && k.comment != nil:
+		// remove whitespace separating the comment
+		if v := k.tail(); v == nil {
+			k.ws = ""
+		} else {
+			*v = strings.TrimRight(*v, " \t")
+		}
+		k.comment = nil
+		s.file.resetCommentSeparator()
+	case comment == "":
+	case k.comment != nil:
+		k.comment.comment = " " + comment
+	default:
+		// separate comment from the value
+		switch {
+		case k.value == nil && k.ws == "":
+			k.ws = " "
+		case k.value != nil && !strings.HasSuffix(*k.tail(), " ") && !strings.HasSuffix(*k.tail(), "\t"):
+			*k.tail() += " "
+		}
+		k.comment = s.file.newComment(comment)
+	}
+	return nil
+}
+
+// KeyDocComment returns the text of the doc comment for key, which is the
+// block of comment lines directly above the key. Multiple lines are separated
+// by '\n'.
+func (s *Section) KeyDocComment(key string) string {
+	k, idx := s.getKey(key)
+	if k == nil {
+		return ""
+	}
+	return s.file.docComment(idx)
+}
+
+// SetKeyDocComment replaces the doc comment for key with a comment line for
+// each line of text, using the comment separator of the File's existing
+// comments. An empty text removes the doc comment.
+//
+// Returns ErrKeyNotFound if key is not defined.
+func (s *Section) SetKeyDocComment(key, text string) error {
+	k, idx := s.getKey(key)
+	if k == nil {
+		return fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	s.file.setDocComment(idx, text)
+	return nil
+}
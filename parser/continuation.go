@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"strings"
+)
+
+// raw returns the key's value as written in the File, including any
+// continuation lines.
+func (kvp *KeyValuePair) raw() string {
+	if kvp.value == nil {
+		return ""
+	}
+	raw := *kvp.value
+	for _, c := range kvp.conts {
+		raw += c.String()
+	}
+	return raw
+}
+
+// tail returns the last physical part of the key's value, which is the part
+// preceding the key's inline comment.
+func (kvp *KeyValuePair) tail() *string {
+	if len(kvp.conts) != 0 {
+		return &kvp.conts[len(kvp.conts)-1].value
+	}
+	return kvp.value
+}
+
+// value returns the logical value for kvp, joining any continuation lines
+// according to the File's continuation style, unquoting quoted values, and
+// passing other values through ValueManipFunc.
+func (f *File) value(kvp *KeyValuePair) string {
+	if kvp == nil || kvp.value == nil {
+		return f.decodeValue("")
+	}
+	if len(kvp.conts) == 0 {
+		return f.decodeValue(*kvp.value)
+	}
+
+	if f.Continuation == IndentContinuation {
+		lines := []string{strings.TrimSpace(*kvp.value)}
+		for _, c := range kvp.conts {
+			lines = append(lines, strings.TrimSpace(c.value))
+		}
+		return f.ValueManipFunc(strings.Join(lines, "\n"))
+	}
+
+	value := strings.TrimSuffix(*kvp.value, `\`)
+	for _, c := range kvp.conts {
+		value += strings.TrimSuffix(c.value, `\`)
+	}
+	return f.ValueManipFunc(value)
+}
+
+// continuationLines splits a value containing line breaks into lines that
+// can be written as continuation lines, returning false if the File does not
+// use the indent style, or if any line would not be parsed back as the same
+// line.
+//
+// Values are not written as continuation lines in the backslash style, as it
+// does not preserve line breaks. Values containing '\r' or empty lines are
+// not written as continuation lines, as they are joined with '\n' and empty
+// lines are not continued, nor are values starting with a delimiter when the
+// key may have a whitespace delimiter.
+func (f *File) continuationLines(value string) ([]string, bool) {
+	if f.Continuation != IndentContinuation || !strings.Contains(value, "\n") || strings.Contains(value, "\r") {
+		return nil, false
+	}
+	lines := strings.Split(value, "\n")
+	if isDelimiter(f.Delimiters, " ") && f.delimiterPrefix(lines[0]) {
+		return nil, false
+	}
+	for _, line := range lines {
+		switch {
+		case line == "",
+			strings.TrimSpace(line) != line,
+			f.needsQuote(line):
+			return nil, false
+		}
+	}
+	return lines, true
+}
+
+// setContinuation sets the continuation lines for key, indenting the lines
+// one level deeper than the key.
+func (s *Section) setContinuation(key string, lines []string) {
+	k, idx := s.getKey(key)
+	if k == nil {
+		return
+	}
+	l := s.file.lines[idx]
+	for _, line := range lines {
+		k.conts = append(k.conts, NewContinuationLine(position{}, l.le, l.ws+"\t", line))
+	}
+	s.file.separateComment(k)
+}
+
+// unindentNext sets the leading whitespace of the lines following k to that
+// of k's line, when they would otherwise be parsed as continuation lines of
+// a value set for k. A key-only entry, or a key being added, can be followed
+// by more indented lines.
+func (f *File) unindentNext(k *KeyValuePair) {
+	if f.Continuation != IndentContinuation {
+		return
+	}
+	idx := f.itemLine(k)
+	if idx < 0 {
+		return
+	}
+	ws := f.lines[idx].ws
+	for _, l := range f.lines[idx+1:] {
+		switch l.item.(type) {
+		case *Section, *KeyValuePair, *Invalid:
+		default:
+			return
+		}
+		if len(l.ws) <= len(ws) {
+			return
+		}
+		l.ws = ws
+	}
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"strings"
+)
+
+// isDelimiter determines if d is one of the key/value delimiters in delims,
+// where a space or tab in delims accepts any whitespace.
+func isDelimiter(delims, d string) bool {
+	switch d {
+	case "":
+		return false
+	case " ", "\t":
+		return strings.ContainsAny(delims, " \t")
+	}
+	return strings.Contains(delims, d)
+}
+
+// delimiter returns the delimiter for new keys, which is the most common
+// delimiter of the File's keys, or the first of the File's Delimiters if the
+// File does not contain any keys with a value.
+func (f *File) delimiter() string {
+	idx := f.index()
+	if !idx.hasDelim {
+		idx.delim, idx.hasDelim = f.keysDelimiter(), true
+	}
+
+	switch {
+	case idx.delim != "":
+		return idx.delim
+	case f.Delimiters == "", isDelimiter(f.Delimiters, DefaultDelimiter):
+		return DefaultDelimiter
+	case isDelimiter(f.Delimiters, " "):
+		return " "
+	}
+	return f.Delimiters[:1]
+}
+
+// keysDelimiter returns the most common delimiter of the File's keys with a
+// value, or "" if there are none.
+func (f *File) keysDelimiter() string {
+	counts := make(map[string]int)
+	var delim string
+	for _, l := range f.lines {
+		kvp, ok := l.item.(*KeyValuePair)
+		if !ok || kvp.value == nil {
+			continue
+		}
+		d := keyDelimiter(kvp)
+		if counts[d]++; counts[d] > counts[delim] {
+			delim = d
+		}
+	}
+	return delim
+}
+
+// keyDelimiter returns the delimiter of kvp, without surrounding whitespace,
+// or " " for a whitespace delimiter.
+func keyDelimiter(kvp *KeyValuePair) string {
+	if d := strings.TrimSpace(kvp.delim); d != "" {
+		return d
+	}
+	return " "
+}
+
+// delimiterPrefix determines if value starts with one of the File's '=' or
+// ':' delimiters, which would be parsed as the delimiter following a
+// whitespace delimiter.
+func (f *File) delimiterPrefix(value string) bool {
+	delims := f.Delimiters
+	if delims == "" {
+		delims = DefaultDelimiter
+	}
+	return value != "" && (value[0] == '=' || value[0] == ':') && isDelimiter(delims, value[:1])
+}
+
+// newKeyValuePair creates a key value pair for key and value using the File's
+// delimiter for new keys.
+func (f *File) newKeyValuePair(key string, value *string) *KeyValuePair {
+	delim := f.delimiter()
+	kvp := NewKeyValuePair(position{}, key, "", f.quoteDelim(delim, value), nil)
+	kvp.delim = delim
+	return kvp
+}
+
+// quoteDelim returns value quoted when delim is a whitespace delimiter and
+// the key would otherwise not be parsed back with value: an empty value is
+// parsed as a key-only entry, and a value starting with a delimiter is parsed
+// as that delimiter.
+func (f *File) quoteDelim(delim string, value *string) *string {
+	if value == nil || strings.TrimSpace(delim) != "" {
+		return value
+	}
+	switch {
+	case *value == "":
+		v := `""`
+		return &v
+	case f.delimiterPrefix(*value):
+		v := quote(*value)
+		return &v
+	}
+	return value
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 )
 
 // File represents parsed ini data.
@@ -14,21 +17,28 @@ type File struct {
 	// sections in file.
 	sections []*Section
 
+	// idx is the index of sections and keys in file, and idxMu guards
+	// building it and the lines recorded in it.
+	idx   *index
+	idxMu sync.Mutex
+
 	// Manipulation function used on section name for AddSection,
 	// RenameSection.
 	SectionManipFunc func(string) string
 
 	// Function used to normalize and format section name for presentation.
+	// See ResetIndex when changing it.
 	SectionNameFunc func(string) string
 
 	// Comparison function used to find section in File. Set this to override
-	// default comparison behavior.
+	// default comparison behavior. See ResetIndex when changing it.
 	SectionCompFunc func(string, string) bool
 
 	// Manipulation function used on key in File.
 	KeyManipFunc func(string) string
 
-	// Comparison function used to find key in File.
+	// Comparison function used to find key in File. See ResetIndex when
+	// changing it.
 	KeyCompFunc func(string, string) bool
 
 	// Manipulation function used when setting value in File.
@@ -36,6 +46,27 @@ type File struct {
 
 	// Function is used to split a key name (such as section.key).
 	NameSplitFunc func(string) (string, string)
+
+	// Leading whitespace used for the first key added to a non-empty
+	// Section.
+	LeadingKeyWhitespace string
+
+	// Continuation style of values spanning several lines, as set by the
+	// Continuation parse option. Used by SetKey for values containing line
+	// breaks.
+	Continuation ContinuationStyle
+
+	// Key/value delimiters accepted when parsing, as set by the Delimiters
+	// parse option. New keys are added using the File's most common
+	// delimiter.
+	Delimiters string
+
+	// Comment prefixes recognized when parsing, as set by the CommentPrefixes
+	// parse option. Values containing a prefix are quoted when needed.
+	CommentPrefixes []string
+
+	// Inline comment policy, as set by the InlineComments parse option.
+	InlineComments InlineCommentPolicy
 }
 
 // NewFile creates a new ini.File from provided lines.
@@ -53,6 +84,10 @@ func NewFile(lines []*Line) *File {
 		KeyCompFunc:      KeyCompFunc,
 		ValueManipFunc:   ValueManipFunc,
 		NameSplitFunc:    NameSplitFunc,
+
+		LeadingKeyWhitespace: DefaultLeadingKeyWhitespace,
+		Delimiters:           DefaultDelimiter,
+		CommentPrefixes:      DefaultCommentPrefixes,
 	}
 
 	// create default section
@@ -84,7 +119,7 @@ func NewFile(lines []*Line) *File {
 // String returns formatted ini file data.
 //
 // Satisfies fmt.Stringer interface.
-func (f File) String() string {
+func (f *File) String() string {
 	var buf bytes.Buffer
 	for _, l := range f.lines {
 		buf.WriteString(l.String())
@@ -97,6 +132,21 @@ func (f *File) AllSections() []*Section {
 	return f.sections
 }
 
+// WriteTo writes formatted ini file data to w.
+//
+// Satisfies the io.WriterTo interface.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, l := range f.lines {
+		i, err := io.WriteString(w, l.String())
+		n += int64(i)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
 // Write to filename.
 func (f *File) Write(filename string) error {
 	file, err := os.Create(filename)
@@ -105,8 +155,11 @@ func (f *File) Write(filename string) error {
 	}
 	defer file.Close()
 
-	_, err = file.WriteString(f.String())
-	return err
+	w := bufio.NewWriter(file)
+	if _, err = f.WriteTo(w); err != nil {
+		return err
+	}
+	return w.Flush()
 }
 
 // LineCount returns the line count.
@@ -137,19 +190,41 @@ func (f *File) SectionNames() []string {
 
 // AddSectionRaw adds a Section to File with a raw (unmanipulated) name.
 //
-// Returns the created Section.
+// Returns the created Section, or nil if the name is not valid. See
+// AddSectionRawErr.
 func (f *File) AddSectionRaw(name string) *Section {
+	s, _ := f.AddSectionRawErr(name)
+	return s
+}
+
+// AddSectionRawErr adds a Section to File with a raw (unmanipulated) name.
+//
+// Returns the created Section, or ErrInvalidSectionName if the name could not
+// be parsed back as the same name (ie, it contains comment characters,
+// brackets, or line endings).
+func (f *File) AddSectionRawErr(name string) (*Section, error) {
 	// if its "", then avoid retrieving ...
 	if f.sectionNameComp(name, "") {
-		return f.GetSection("")
+		return f.GetSection(""), nil
+	}
+
+	if err := f.validSectionName(name); err != nil {
+		return nil, err
 	}
 
+	return f.appendSection(name), nil
+}
+
+// appendSection creates a Section with the raw name, adding it to the end of
+// the File.
+func (f *File) appendSection(name string) *Section {
 	// create section
 	s := NewSection(position{}, name, "", nil)
 	s.file = f
 
 	// add section data to file
 	f.sections = append(f.sections, s)
+	f.indexSection(s)
 
 	if len(f.lines) > 0 && f.lines[len(f.lines)-1].item == nil {
 		// if it's a blank line on the last line, then put it there
@@ -174,11 +249,21 @@ func (f *File) AddSectionRaw(name string) *Section {
 //
 // Section name is passed through file's SectionManipFunc.
 //
-// Returns the created Section.
+// Returns the created Section, or nil if the name is not valid. See
+// AddSectionErr.
 func (f *File) AddSection(name string) *Section {
 	return f.AddSectionRaw(f.SectionManipFunc(name))
 }
 
+// AddSectionErr adds a Section to File, returning an error if the name is not
+// valid.
+//
+// Section name is passed through file's SectionManipFunc. See
+// AddSectionRawErr.
+func (f *File) AddSectionErr(name string) (*Section, error) {
+	return f.AddSectionRawErr(f.SectionManipFunc(name))
+}
+
 // sectionNameComp compares provided Section names to determine if they are
 // equal.
 //
@@ -194,37 +279,84 @@ func (f *File) sectionNameComp(a, b string) bool {
 
 // getSection Get a section and its starting line number.
 func (f *File) getSection(name string) (*Section, int) {
+	switch s := f.section(f.SectionManipFunc(name)); {
+	case s == nil:
+		return nil, -1
+	case s == f.sections[0]:
+		return s, 0
+	default:
+		return s, s.lineIndex()
+	}
+}
+
+// GetSection returns a Section with provided name from File.
+//
+// When there are multiple sections with the same name, the first is
+// returned. See GetSections.
+func (f *File) GetSection(name string) *Section {
+	return f.section(f.SectionManipFunc(name))
+}
+
+// GetSections returns all sections with provided name from File, in the
+// order they are in the File.
+func (f *File) GetSections(name string) []*Section {
 	n := f.SectionManipFunc(name)
 
 	// blank section isn't actually defined ...
 	if f.sectionNameComp(n, "") {
-		return f.sections[0], 0
+		return []*Section{f.sections[0]}
 	}
 
-	// loop through lines and find section
-	for idx, line := range f.lines {
-		if s, ok := line.item.(*Section); ok && f.sectionNameComp(n, s.name) {
-			return s, idx
+	if idx := f.index(); idx.sectionNorm != nil {
+		return append([]*Section(nil), idx.sections[idx.sectionNorm(n)]...)
+	}
+
+	var sections []*Section
+	for _, s := range f.sections[1:] {
+		if f.sectionNameComp(n, s.name) {
+			sections = append(sections, s)
 		}
 	}
+	return sections
+}
 
-	return nil, -1
+// GetSectionAt returns the Section at index idx of the sections with
+// provided name from File, or nil if there is no such Section.
+func (f *File) GetSectionAt(name string, idx int) *Section {
+	sections := f.GetSections(name)
+	if idx < 0 || idx >= len(sections) {
+		return nil
+	}
+	return sections[idx]
 }
 
-// GetSection returns a Section with provided name from File.
-func (f *File) GetSection(name string) *Section {
-	s, _ := f.getSection(name)
-	return s
+// AppendSection adds a new Section to the end of File, even when a Section
+// with the same name already exists.
+//
+// Section name is passed through file's SectionManipFunc. Returns
+// ErrInvalidSectionName if the name is not valid, or is the empty name.
+func (f *File) AppendSection(name string) (*Section, error) {
+	n := f.SectionManipFunc(name)
+	if f.sectionNameComp(n, "") {
+		return nil, fmt.Errorf("%q: %w", name, ErrInvalidSectionName)
+	}
+	if err := f.validSectionName(n); err != nil {
+		return nil, err
+	}
+	return f.appendSection(n), nil
 }
 
 // SetMap sets all section and key values from provided map.
 //
-// Replaces values if the key already exists, or adds them otherwise.
+// Replaces values if the key already exists, or adds them otherwise. Sections
+// and keys with names that are not valid are skipped.
 func (f *File) SetMap(values map[string]map[string]string) {
 	for name, keys := range values {
 		section := f.GetSection(name)
 		if section == nil {
-			section = f.AddSection(name)
+			if section = f.AddSection(name); section == nil {
+				continue
+			}
 		}
 
 		for k, v := range keys {
@@ -234,13 +366,16 @@ func (f *File) SetMap(values map[string]map[string]string) {
 }
 
 // GetMap returns all sections and key values as map.
+//
+// Values are retrieved using Section.Get, with key-only entries reported as
+// FlagValue.
 func (f *File) GetMap() map[string]map[string]string {
 	ret := make(map[string]map[string]string)
 
 	for _, section := range f.sections {
 		s := make(map[string]string)
 		for _, key := range section.keys {
-			s[f.KeyManipFunc(key)] = f.ValueManipFunc(section.GetRaw(key))
+			s[f.KeyManipFunc(key)] = section.mapValue(key)
 		}
 
 		ret[section.Name()] = s
@@ -249,6 +384,27 @@ func (f *File) GetMap() map[string]map[string]string {
 	return ret
 }
 
+// GetMapAll returns all sections and key values as a map of slices, with an
+// entry in the slice for each Section with the same name.
+//
+// Values are retrieved using Section.Get, with key-only entries reported as
+// FlagValue.
+func (f *File) GetMapAll() map[string][]map[string]string {
+	ret := make(map[string][]map[string]string)
+
+	for _, section := range f.sections {
+		s := make(map[string]string)
+		for _, key := range section.keys {
+			s[f.KeyManipFunc(key)] = section.mapValue(key)
+		}
+
+		name := section.Name()
+		ret[name] = append(ret[name], s)
+	}
+
+	return ret
+}
+
 // SetMapFlat sets section and key values from a flat map.
 func (f *File) SetMapFlat(values map[string]string) {
 	for key, value := range values {
@@ -257,6 +413,8 @@ func (f *File) SetMapFlat(values map[string]string) {
 }
 
 // GetMapFlat retrieves all sections and keys and values as flat map.
+//
+// Key-only entries are reported as FlagValue.
 func (f *File) GetMapFlat() map[string]string {
 	ret := make(map[string]string)
 
@@ -267,7 +425,7 @@ func (f *File) GetMapFlat() map[string]string {
 		}
 
 		for _, key := range section.keys {
-			ret[fmt.Sprintf("%s%s", name, key)] = f.ValueManipFunc(section.GetRaw(key))
+			ret[fmt.Sprintf("%s%s", name, key)] = section.mapValue(key)
 		}
 	}
 
@@ -276,6 +434,8 @@ func (f *File) GetMapFlat() map[string]string {
 
 // GetAllFlat retrieves all sections and keys in the order they are in the
 // file, as set of name, values.
+//
+// Key-only entries are reported as FlagValue.
 func (f *File) GetAllFlat() []string {
 	var ret []string
 	for _, section := range f.sections {
@@ -284,44 +444,121 @@ func (f *File) GetAllFlat() []string {
 			name = fmt.Sprintf("%s%s", name, DefaultNameKeySeparator)
 		}
 		for _, key := range section.keys {
-			ret = append(ret, fmt.Sprintf("%s%s", name, key), f.ValueManipFunc(section.GetRaw(key)))
+			ret = append(ret, fmt.Sprintf("%s%s", name, key), section.mapValue(key))
 		}
 	}
 	return ret
 }
 
 // RenameSectionRaw renames a Section in File using raw (unmanipulated) names.
+//
+// The Section is not renamed if the new name is not valid. See
+// RenameSectionRawErr.
 func (f *File) RenameSectionRaw(name, value string) {
+	_ = f.RenameSectionRawErr(name, value)
+}
+
+// RenameSectionRawErr renames a Section in File using raw (unmanipulated)
+// names.
+//
+// Returns ErrSectionNotFound if the Section does not exist, or
+// ErrInvalidSectionName if the new name is not valid.
+func (f *File) RenameSectionRawErr(name, value string) error {
 	s := f.GetSection(name)
-	s.name = value
+	if s == nil {
+		return fmt.Errorf("%q: %w", name, ErrSectionNotFound)
+	}
+	if err := f.validSectionName(value); err != nil {
+		return err
+	}
+	f.renameSection(s, value)
+	return nil
+}
+
+// RenameSectionAt renames the Section at index idx of the sections with
+// provided name.
+//
+// Value will be passed through the File's SectionManipFunc. Returns
+// ErrSectionNotFound if there is no such Section, or ErrInvalidSectionName if
+// the new name is not valid.
+func (f *File) RenameSectionAt(name string, idx int, value string) error {
+	s := f.GetSectionAt(name, idx)
+	if s == nil || s == f.sections[0] {
+		return fmt.Errorf("%q[%d]: %w", name, idx, ErrSectionNotFound)
+	}
+	value = f.SectionManipFunc(value)
+	if err := f.validSectionName(value); err != nil {
+		return err
+	}
+	f.renameSection(s, value)
+	return nil
 }
 
 // RenameSection renames a Section in File.
 //
-// Value will be passed through the File's SectionManipFunc.
+// Value will be passed through the File's SectionManipFunc. See
+// RenameSectionErr.
 func (f *File) RenameSection(name, value string) {
 	f.RenameSectionRaw(name, f.SectionManipFunc(value))
 }
 
+// RenameSectionErr renames a Section in File, returning an error if the
+// Section does not exist or the new name is not valid.
+//
+// Value will be passed through the File's SectionManipFunc.
+func (f *File) RenameSectionErr(name, value string) error {
+	return f.RenameSectionRawErr(name, f.SectionManipFunc(value))
+}
+
+// sectionRange returns the start and end index of the lines belonging to
+// the Section with header line idx, which are the Section's doc comment,
+// header, keys, and any other lines up to the doc comment of the next
+// Section.
+func (f *File) sectionRange(idx int) (int, int) {
+	end := idx + 1
+	for ; end < len(f.lines); end++ {
+		if _, ok := f.lines[end].item.(*Section); ok {
+			return f.docStart(idx), f.docStart(end)
+		}
+	}
+	return f.docStart(idx), end
+}
+
 // RemoveSection removes a Section and all related lines from File.
+//
+// The Section's doc comment (the comment lines directly above the Section
+// header) is also removed, while the doc comment of the following Section is
+// preserved. When there are multiple sections with the same name, only the
+// first is removed. See RemoveSectionAt.
 func (f *File) RemoveSection(name string) {
-	section, start := f.getSection(name)
-	if section == nil {
+	section, idx := f.getSection(name)
+	if section == nil || section == f.sections[0] {
 		return
 	}
+	f.removeSectionLines(section, idx)
+}
 
+// RemoveSectionAt removes the Section at index idx of the sections with
+// provided name, and all related lines from File.
+//
+// Returns ErrSectionNotFound if there is no such Section.
+func (f *File) RemoveSectionAt(name string, idx int) error {
+	section := f.GetSectionAt(name, idx)
+	if section == nil || section == f.sections[0] {
+		return fmt.Errorf("%q[%d]: %w", name, idx, ErrSectionNotFound)
+	}
+	f.removeSectionLines(section, section.lineIndex())
+	return nil
+}
+
+// removeSectionLines removes section with header line idx, and all related
+// lines from File.
+func (f *File) removeSectionLines(section *Section, idx int) {
 	// save copy of line ending
 	le := f.lines[0].le
 
-	// find next section
-	end := start + 1
-	for ; end < len(f.lines); end++ {
-		if _, ok := f.lines[end].item.(*Section); ok {
-			break
-		}
-	}
-
 	// remove from f.lines
+	start, end := f.sectionRange(idx)
 	f.lines = append(f.lines[:start], f.lines[end:]...)
 
 	// if we removed all lines, then put a blank line back in
@@ -330,34 +567,122 @@ func (f *File) RemoveSection(name string) {
 		f.lines = []*Line{line}
 	}
 
-	// find in f.sections
-	pos := -1
+	f.removeSection(section)
+}
+
+// removeSection removes section from f.sections.
+func (f *File) removeSection(section *Section) {
+	f.unindexSection(section)
 	for idx, s := range f.sections {
 		if section == s {
-			pos = idx
-			break
+			f.sections = append(f.sections[:idx], f.sections[idx+1:]...)
+			return
+		}
+	}
+}
+
+// MoveSection moves a Section, along with its doc comment, keys and
+// comments, to before the Section before. If before is empty, then the
+// Section is moved to the end of the File.
+//
+// Returns ErrSectionNotFound if either Section does not exist, or if name
+// is the empty (first) section, which can not be moved.
+func (f *File) MoveSection(name, before string) error {
+	section, idx := f.getSection(name)
+	if section == nil || section == f.sections[0] {
+		return fmt.Errorf("%q: %w", name, ErrSectionNotFound)
+	}
+	var dest *Section
+	if before != "" {
+		if dest = f.GetSection(before); dest == nil {
+			return fmt.Errorf("%q: %w", before, ErrSectionNotFound)
+		}
+		if dest == section {
+			return nil
 		}
 	}
 
-	// remove from f.sections
-	f.sections = append(f.sections[:pos], f.sections[pos+1:]...)
+	// remove lines
+	start, end := f.sectionRange(idx)
+	lines := append([]*Line(nil), f.lines[start:end]...)
+	f.lines = append(f.lines[:start], f.lines[end:]...)
+	f.removeSection(section)
+
+	// insert lines
+	pos, i := len(f.lines), len(f.sections)
+	if dest != nil {
+		pos, _ = f.sectionRange(dest.lineIndex())
+		for i = 0; f.sections[i] != dest; i++ {
+		}
+	}
+	f.lines = append(f.lines[:pos], append(lines, f.lines[pos:]...)...)
+	f.sections = append(f.sections[:i], append([]*Section{section}, f.sections[i:]...)...)
+	f.ResetIndex()
+	return nil
 }
 
 // SetKey sets a key's value in File with name in form of section.key.
 //
 // If no section is specified, then the empty (first) section is used.
 //
-// Uses File's NameSplitFunc to split the key.
+// Uses File's NameSplitFunc to split the key. The key is not set if the
+// section or key name is not valid. See SetKeyErr.
 func (f *File) SetKey(key, value string) {
+	_ = f.SetKeyErr(key, value)
+}
+
+// SetKeyErr sets a key's value in File with name in form of section.key,
+// returning an error if the section or key name is not valid.
+//
+// If no section is specified, then the empty (first) section is used.
+//
+// Uses File's NameSplitFunc to split the key.
+func (f *File) SetKeyErr(key, value string) error {
+	section, k, err := f.keySection(key)
+	if err != nil {
+		return err
+	}
+	return section.SetKeyErr(k, value)
+}
+
+// keySection splits key using NameSplitFunc, returning the Section and the
+// key name. If the Section does not exist, then it is created.
+func (f *File) keySection(key string) (*Section, string, error) {
+	name, k := f.NameSplitFunc(key)
+
+	// get the section
+	if section := f.GetSection(name); section != nil {
+		return section, k, nil
+	}
+
+	// validate key before creating the section
+	if err := f.validKey(f.KeyManipFunc(k)); err != nil {
+		return nil, "", err
+	}
+	section, err := f.AddSectionErr(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return section, k, nil
+}
+
+// GetKeyRaw retrieves a stored key's raw (unmanipulated) value from File with
+// name in form of section.key.
+//
+// Quoted values are returned as-is, including the quotes and any escape
+// sequences.
+//
+// Uses File's NameSplitFunc to split the key.
+func (f *File) GetKeyRaw(key string) string {
 	name, k := f.NameSplitFunc(key)
 
 	// get the section
 	section := f.GetSection(name)
 	if section == nil {
-		section = f.AddSection(name)
+		return ""
 	}
 
-	section.SetKey(k, value)
+	return section.GetRaw(k)
 }
 
 // GetKey retrieves a stored key's value from File with name in form of
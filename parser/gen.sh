@@ -1,3 +0,0 @@
-#!/bin/bash
-
-pigeon ini.peg | goimports | gofmt -s > pigeon.go
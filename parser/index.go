@@ -0,0 +1,355 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+)
+
+// index is an index of the sections and keys in a File, used to find
+// sections and keys by name without scanning all of the File's lines.
+//
+// The index records the last known line of the sections and keys whose line
+// was looked up. Lines shift as lines are inserted and removed, so the line
+// of an item is found by searching outward from its last known line.
+//
+// The index is built when first used, and is updated as sections and keys
+// are added and removed. Operations that reorder sections or keys discard
+// the index, so that it is rebuilt when next used.
+//
+// The index also records the delimiter and comment separator for new keys
+// and comments, which are determined when first used and discarded when
+// keys or comments are removed.
+type index struct {
+	// funcs are the identities of the File's comparison funcs when the index
+	// was built.
+	funcs [3]uintptr
+
+	// sectionNorm and keyNorm normalize section names and keys so that two
+	// names are equal when their normalized names are equal. A nil func
+	// indicates that the File's comparison func is not known, and that names
+	// are not indexed.
+	sectionNorm func(string) string
+	keyNorm     func(string) string
+
+	// sections are the named sections, by normalized name, in file order.
+	sections map[string][]*Section
+
+	// keys are the keys of each section, by normalized key, in file order.
+	keys map[*Section]map[string][]*KeyValuePair
+
+	// lines are the last known line of items, guarded by the File's idxMu.
+	lines map[interface{}]int
+
+	// delim is the most common delimiter of the File's keys with a value,
+	// and cs is the separator of the File's first comment, or "" when there
+	// are none. They are valid when hasDelim and hasCS are set.
+	delim, cs       string
+	hasDelim, hasCS bool
+}
+
+// funcID returns the identity of the func fn.
+func funcID(fn interface{}) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
+
+// compFuncs returns the identities of the File's section and key comparison
+// funcs.
+func (f *File) compFuncs() [3]uintptr {
+	return [3]uintptr{
+		funcID(f.SectionCompFunc),
+		funcID(f.SectionNameFunc),
+		funcID(f.KeyCompFunc),
+	}
+}
+
+// sectionNorm returns the func that normalizes section names for the File's
+// section comparison func, or nil if the comparison func is not known.
+func (f *File) sectionNorm() func(string) string {
+	switch funcID(f.SectionCompFunc) {
+	case 0:
+		return f.SectionNameFunc
+	case funcID(foldComp):
+		return foldName
+	case funcID(exactComp):
+		return strings.TrimSpace
+	}
+	return nil
+}
+
+// keyNorm returns the func that normalizes keys for the File's key
+// comparison func, or nil if the comparison func is not known.
+func (f *File) keyNorm() func(string) string {
+	switch funcID(f.KeyCompFunc) {
+	case funcID(KeyCompFunc):
+		return KeyManipFunc
+	case funcID(foldComp):
+		return foldName
+	case funcID(exactComp):
+		return strings.TrimSpace
+	}
+	return nil
+}
+
+// index returns the File's index, building it if it has not been built or
+// if the File's comparison funcs have changed.
+//
+// The index is built by methods that do not otherwise change the File, so
+// building it is guarded by idxMu.
+func (f *File) index() *index {
+	f.idxMu.Lock()
+	defer f.idxMu.Unlock()
+	funcs := f.compFuncs()
+	if f.idx != nil && f.idx.funcs == funcs {
+		return f.idx
+	}
+
+	f.idx = &index{
+		funcs:       funcs,
+		sectionNorm: f.sectionNorm(),
+		keyNorm:     f.keyNorm(),
+		sections:    make(map[string][]*Section),
+		keys:        make(map[*Section]map[string][]*KeyValuePair),
+		lines:       make(map[interface{}]int),
+	}
+	for _, s := range f.sections[1:] {
+		f.indexSection(s)
+	}
+	cur := f.sections[0]
+	for _, l := range f.lines {
+		switch v := l.item.(type) {
+		case *Section:
+			cur = v
+		case *KeyValuePair:
+			f.indexKey(cur, v)
+		}
+	}
+	return f.idx
+}
+
+// ResetIndex discards the File's index of sections and keys, so that it is
+// rebuilt when next used.
+//
+// The index is rebuilt when SectionNameFunc, SectionCompFunc or KeyCompFunc
+// is changed to a different func, but closures created from the same
+// function literal can not be told apart. Call ResetIndex after assigning
+// such a func.
+func (f *File) ResetIndex() {
+	f.idx = nil
+}
+
+// indexSection adds section to the end of the sections with the same name in
+// the index.
+func (f *File) indexSection(section *Section) {
+	if f.idx == nil || f.idx.sectionNorm == nil {
+		return
+	}
+	n := f.idx.sectionNorm(section.name)
+	f.idx.sections[n] = append(f.idx.sections[n], section)
+}
+
+// unindexSection removes section and its keys from the index.
+func (f *File) unindexSection(section *Section) {
+	if f.idx == nil {
+		return
+	}
+	f.idx.hasDelim, f.idx.hasCS = false, false
+	delete(f.idx.keys, section)
+	f.idxMu.Lock()
+	delete(f.idx.lines, section)
+	f.idxMu.Unlock()
+	if f.idx.sectionNorm == nil {
+		return
+	}
+	n := f.idx.sectionNorm(section.name)
+	f.idx.sections[n] = remove(f.idx.sections[n], section)
+	if len(f.idx.sections[n]) == 0 {
+		delete(f.idx.sections, n)
+	}
+}
+
+// renameSection sets the raw name of section, moving it to its place among
+// the sections with the new name in the index.
+func (f *File) renameSection(section *Section, name string) {
+	if f.idx == nil || f.idx.sectionNorm == nil {
+		section.name = name
+		return
+	}
+	n := f.idx.sectionNorm(section.name)
+	if f.idx.sections[n] = remove(f.idx.sections[n], section); len(f.idx.sections[n]) == 0 {
+		delete(f.idx.sections, n)
+	}
+	section.name = name
+	n = f.idx.sectionNorm(name)
+	var sections []*Section
+	for _, s := range f.sections[1:] {
+		if s == section || f.idx.sectionNorm(s.name) == n {
+			sections = append(sections, s)
+		}
+	}
+	f.idx.sections[n] = sections
+}
+
+// indexKey adds kvp to the end of the keys with the same name in section in
+// the index.
+func (f *File) indexKey(section *Section, kvp *KeyValuePair) {
+	if f.idx == nil {
+		return
+	}
+	if kvp.value != nil && f.idx.hasDelim && keyDelimiter(kvp) != f.idx.delim {
+		f.idx.hasDelim = false
+	}
+	if f.idx.keyNorm == nil {
+		return
+	}
+	keys := f.idx.keys[section]
+	if keys == nil {
+		keys = make(map[string][]*KeyValuePair)
+		f.idx.keys[section] = keys
+	}
+	n := f.idx.keyNorm(kvp.key)
+	keys[n] = append(keys[n], kvp)
+}
+
+// unindexKey removes kvp in section from the index.
+func (f *File) unindexKey(section *Section, kvp *KeyValuePair) {
+	if f.idx == nil {
+		return
+	}
+	f.idx.hasDelim, f.idx.hasCS = false, false
+	f.idxMu.Lock()
+	delete(f.idx.lines, kvp)
+	f.idxMu.Unlock()
+	if f.idx.keyNorm == nil {
+		return
+	}
+	keys := f.idx.keys[section]
+	n := f.idx.keyNorm(kvp.key)
+	if keys[n] = remove(keys[n], kvp); len(keys[n]) == 0 {
+		delete(keys, n)
+	}
+}
+
+// resetDelimiter discards the delimiter for new keys recorded in the index.
+func (f *File) resetDelimiter() {
+	if f.idx != nil {
+		f.idx.hasDelim = false
+	}
+}
+
+// resetCommentSeparator discards the comment separator for new comments
+// recorded in the index.
+func (f *File) resetCommentSeparator() {
+	if f.idx != nil {
+		f.idx.hasCS = false
+	}
+}
+
+// remove returns v with the first occurrence of item removed.
+func remove[T comparable](v []T, item T) []T {
+	for i, x := range v {
+		if x == item {
+			return append(v[:i:i], v[i+1:]...)
+		}
+	}
+	return v
+}
+
+// section returns the first Section with the (manipulated) name n, or nil if
+// there is no such Section.
+func (f *File) section(n string) *Section {
+	// blank section isn't actually defined ...
+	if f.sectionNameComp(n, "") {
+		return f.sections[0]
+	}
+
+	if idx := f.index(); idx.sectionNorm != nil {
+		if sections := idx.sections[idx.sectionNorm(n)]; len(sections) != 0 {
+			return sections[0]
+		}
+		return nil
+	}
+
+	for _, s := range f.sections[1:] {
+		if f.sectionNameComp(n, s.name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// itemLine returns the line index of item, or -1 if item is not in the
+// File, recording the line in the index.
+func (f *File) itemLine(item interface{}) int {
+	idx := f.index()
+	f.idxMu.Lock()
+	start, ok := idx.lines[item]
+	f.idxMu.Unlock()
+
+	i := -1
+	if ok {
+		i = f.findLine(item, start)
+	} else {
+		for j, l := range f.lines {
+			if l.item == item {
+				i = j
+				break
+			}
+		}
+	}
+	if i >= 0 {
+		f.idxMu.Lock()
+		idx.lines[item] = i
+		f.idxMu.Unlock()
+	}
+	return i
+}
+
+// findLine returns the line index of item, searching outward from line
+// start, or -1 if item is not in the File.
+func (f *File) findLine(item interface{}, start int) int {
+	n := len(f.lines)
+	start = min(start, n-1)
+	for d := 0; start-d >= 0 || start+d < n; d++ {
+		if i := start + d; i < n && f.lines[i].item == item {
+			return i
+		}
+		if i := start - d; d != 0 && i >= 0 && f.lines[i].item == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// key returns the first KeyValuePair in Section for key, or nil if key is
+// not defined.
+func (s *Section) key(key string) *KeyValuePair {
+	if idx := s.file.index(); idx.keyNorm != nil {
+		if kvps := idx.keys[s][idx.keyNorm(key)]; len(kvps) != 0 {
+			return kvps[0]
+		}
+		return nil
+	}
+
+	for _, kvp := range s.kvps() {
+		if s.file.KeyCompFunc(kvp.key, key) {
+			return kvp
+		}
+	}
+	return nil
+}
+
+// insertLocation returns the position a new key should be inserted at in
+// Section, which is after the Section's last non-blank line, before the doc
+// comment of the next Section.
+func (s *Section) insertLocation() int {
+	start := 0
+	if s != s.file.sections[0] {
+		start = s.lineIndex() + 1
+	}
+	for idx := start; idx < len(s.file.lines); idx++ {
+		if _, ok := s.file.lines[idx].item.(*Section); ok {
+			return s.getInsertLocation(s.file.docStart(idx) - 1)
+		}
+	}
+	return s.getInsertLocation(len(s.file.lines) - 1)
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"iter"
+)
+
+// kvps returns the key value pairs in Section, in the order they are in the
+// File, including repeated keys.
+func (s *Section) kvps() []*KeyValuePair {
+	var kvps []*KeyValuePair
+	cur := s.file.sections[0]
+	for _, l := range s.file.lines {
+		switch v := l.item.(type) {
+		case *Section:
+			cur = v
+		case *KeyValuePair:
+			if cur == s {
+				kvps = append(kvps, v)
+			}
+		}
+	}
+	return kvps
+}
+
+// kvps returns an iterator over the key value pairs in File and the Section
+// each is in, in the order they are in the File, walking the lines once.
+func (f *File) kvps() iter.Seq2[*Section, *KeyValuePair] {
+	return func(yield func(*Section, *KeyValuePair) bool) {
+		cur := f.sections[0]
+		for _, l := range f.lines {
+			switch v := l.item.(type) {
+			case *Section:
+				cur = v
+			case *KeyValuePair:
+				if !yield(cur, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// entryValue returns the value for kvp as reported by the iterators, which is
+// FlagValue for key-only entries.
+func (f *File) entryValue(kvp *KeyValuePair) string {
+	if kvp.value == nil {
+		return FlagValue
+	}
+	return f.value(kvp)
+}
+
+// Sections returns an iterator over the sections in File, in the order they
+// are in the file. The unnamed (default) section is always first.
+func (f *File) Sections() iter.Seq[*Section] {
+	return func(yield func(*Section) bool) {
+		for _, section := range f.AllSections() {
+			if !yield(section) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the keys and values in Section, in the order
+// they are in the file. Repeated keys are yielded once for each occurrence.
+//
+// Keys are passed through KeyManipFunc, and values are retrieved in the same
+// way as Section.Get, with key-only entries reported as FlagValue.
+func (s *Section) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, kvp := range s.kvps() {
+			if !yield(s.file.KeyManipFunc(kvp.key), s.file.entryValue(kvp)) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the flat names (as used by File.GetKey) and
+// values of all keys in File, in the order they are in the file. Repeated
+// keys are yielded once for each occurrence.
+//
+// Values are retrieved in the same way as Section.All.
+func (f *File) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		var cur *Section
+		var name string
+		for section, kvp := range f.kvps() {
+			if section != cur {
+				cur, name = section, section.Name()
+				if section.name != "" {
+					name += DefaultNameKeySeparator
+				}
+			}
+			if !yield(name+f.KeyManipFunc(kvp.key), f.entryValue(kvp)) {
+				return
+			}
+		}
+	}
+}
+
+// Entry is a key and value in a Snapshot.
+type Entry struct {
+	// Key is the key name, passed through KeyManipFunc.
+	Key string
+
+	// Value is the key's value, retrieved in the same way as Section.Get.
+	// Key-only entries have an empty value.
+	Value string
+
+	// Flag is true when the key is a key-only entry (ie, has no value).
+	Flag bool
+}
+
+// SectionSnapshot is an ordered copy of the keys and values in a Section.
+type SectionSnapshot struct {
+	// Name is the section name, as returned by Section.Name.
+	Name string
+
+	// Entries are the keys and values in the section, in the order they
+	// are in the file, including repeated keys.
+	Entries []Entry
+}
+
+// Get returns the value of the first occurrence of key in the section, as
+// with Section.Get, and whether or not the key was found. Key-only entries
+// are reported as FlagValue.
+//
+// Keys are compared exactly; use the names as passed through KeyManipFunc.
+func (s SectionSnapshot) Get(key string) (string, bool) {
+	for _, e := range s.Entries {
+		if e.Key == key {
+			if e.Flag {
+				return FlagValue, true
+			}
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Snapshot is an ordered copy of the sections, keys and values in a File,
+// preserving the order of the file, repeated sections and keys, and key-only
+// entries.
+//
+// Unlike GetMap, a Snapshot has one entry for each section in the file,
+// including the unnamed (default) section, which is always first.
+type Snapshot []SectionSnapshot
+
+// Snapshot returns an ordered copy of the sections, keys and values in File.
+//
+// The Snapshot is not updated when File is changed.
+func (f *File) Snapshot() Snapshot {
+	snap := make(Snapshot, len(f.sections))
+	idxs := make(map[*Section]int, len(f.sections))
+	for i, section := range f.sections {
+		snap[i].Name, idxs[section] = section.Name(), i
+	}
+	for section, kvp := range f.kvps() {
+		e := Entry{
+			Key:  f.KeyManipFunc(kvp.key),
+			Flag: kvp.value == nil,
+		}
+		if !e.Flag {
+			e.Value = f.value(kvp)
+		}
+		s := &snap[idxs[section]]
+		s.Entries = append(s.Entries, e)
+	}
+	return snap
+}
+
+// Sections returns all sections in the Snapshot with the provided name, in
+// the order they are in the file.
+//
+// Names are compared exactly; use the names as returned by Section.Name.
+func (snap Snapshot) Sections(name string) []SectionSnapshot {
+	var sections []SectionSnapshot
+	for _, s := range snap {
+		if s.Name == name {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,563 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse errors.
+var (
+	// ErrSyntax is the error returned when ini data cannot be parsed.
+	ErrSyntax = errors.New("syntax error")
+
+	// ErrInvalidEncoding is the error returned when ini data is not valid
+	// UTF-8.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+)
+
+// config is the parse configuration set by Options.
+type config struct {
+	lenient         bool
+	continuation    ContinuationStyle
+	delimiters      string
+	commentPrefixes []string
+	inlineComments  InlineCommentPolicy
+	caseMode        CaseMode
+
+	limits           limits
+	allowInvalidUTF8 bool
+}
+
+// newConfig creates the parse configuration for opts.
+func newConfig(opts ...Option) *config {
+	cfg := new(config)
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.delimiters == "" {
+		cfg.delimiters = DefaultDelimiter
+	}
+	if len(cfg.commentPrefixes) == 0 {
+		cfg.commentPrefixes = DefaultCommentPrefixes
+	}
+	return cfg
+}
+
+// invalidUTF8 returns the offset of the first invalid UTF-8 sequence in s, or
+// -1 if s is valid UTF-8.
+func invalidUTF8(s string) int {
+	if utf8.ValidString(s) {
+		return -1
+	}
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
+
+// lexer scans ini data into Lines.
+//
+// The lexer works on a string copy of the data, so that the text of each
+// item is a substring of the data and does not need to be allocated
+// separately.
+type lexer struct {
+	cfg *config
+	src string
+
+	// delimiter and comment prefix configuration.
+	eq, colon, space bool
+	prefixes         []string
+	prefixStart      [utf8.RuneSelf]bool
+	prefixNonASCII   bool
+
+	// indent is the leading whitespace of the current line.
+	indent string
+
+	// max is the farthest position a match failed on the current line, and
+	// farthest is the farthest position a match failed on any line.
+	max, farthest int
+
+	// line and lineStart are the line number and offset of the last
+	// computed position.
+	line, lineStart, last int
+
+	// short is set when a quoted value or escape sequence runs past the end
+	// of src, and more data could change how the line is scanned.
+	short bool
+}
+
+// newLexer creates a lexer for src.
+func newLexer(src string, cfg *config) *lexer {
+	l := &lexer{
+		cfg:   cfg,
+		src:   src,
+		eq:    isDelimiter(cfg.delimiters, "="),
+		colon: isDelimiter(cfg.delimiters, ":"),
+		space: isDelimiter(cfg.delimiters, " "),
+		line:  1,
+	}
+
+	// only prefixes up to three characters long are matched, longest first
+	for n := 3; n > 0; n-- {
+		for _, prefix := range cfg.commentPrefixes {
+			if utf8.RuneCountInString(prefix) != n {
+				continue
+			}
+			l.prefixes = append(l.prefixes, prefix)
+			if c := prefix[0]; c < utf8.RuneSelf {
+				l.prefixStart[c] = true
+			} else {
+				l.prefixNonASCII = true
+			}
+		}
+	}
+	return l
+}
+
+// pos returns the position of offset i.
+//
+// Positions are usually requested in increasing order, so lines are counted
+// from the last requested position.
+func (l *lexer) pos(i int) position {
+	if i < l.last {
+		l.line, l.lineStart, l.last = 1, 0, 0
+	}
+	for ; l.last < i; l.last++ {
+		if l.src[l.last] == '\n' {
+			l.line, l.lineStart = l.line+1, l.last+1
+		}
+	}
+	return position{
+		line:   l.line,
+		col:    utf8.RuneCountInString(l.src[l.lineStart:i]) + 1,
+		offset: i,
+	}
+}
+
+// fail records that a match failed at i.
+func (l *lexer) fail(i int) {
+	if i > l.max {
+		l.max = i
+	}
+}
+
+// err returns the error for the farthest failed match.
+func (l *lexer) err() (int, error) {
+	i := l.farthest
+	if l.max > i {
+		i = l.max
+	}
+	return i, l.errAt(i)
+}
+
+// errAt returns the error for a failed match at i.
+func (l *lexer) errAt(i int) error {
+	switch {
+	case i >= len(l.src):
+		return fmt.Errorf("%w: unexpected end of input", ErrSyntax)
+	case l.lineEnd(i) > i:
+		return fmt.Errorf("%w: unexpected end of line", ErrSyntax)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[i:])
+	return fmt.Errorf("%w: unexpected %q", ErrSyntax, r)
+}
+
+// next returns the offset of the character following the character at i.
+func (l *lexer) next(i int) int {
+	if l.src[i] < utf8.RuneSelf {
+		return i + 1
+	}
+	_, n := utf8.DecodeRuneInString(l.src[i:])
+	return i + n
+}
+
+// lineEnd returns the end of the line ending at i, or i if there is no line
+// ending at i.
+func (l *lexer) lineEnd(i int) int {
+	switch {
+	case i < len(l.src) && l.src[i] == '\n':
+		return i + 1
+	case i+1 < len(l.src) && l.src[i] == '\r' && l.src[i+1] == '\n':
+		return i + 2
+	}
+	return i
+}
+
+// space returns the end of the spaces and tabs starting at i.
+func (l *lexer) spaces(i int) int {
+	for i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// prefix returns the end of the comment prefix starting at i, or i if there
+// is no comment prefix at i.
+func (l *lexer) prefix(i int) int {
+	if i >= len(l.src) {
+		return i
+	}
+	if c := l.src[i]; c < utf8.RuneSelf && !l.prefixStart[c] || c >= utf8.RuneSelf && !l.prefixNonASCII {
+		return i
+	}
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(l.src[i:], prefix) {
+			return i + len(prefix)
+		}
+	}
+	return i
+}
+
+// inlineComment returns whether or not an inline comment starts at i,
+// according to the inline comment policy.
+func (l *lexer) inlineComment(i int) bool {
+	switch l.cfg.inlineComments {
+	case InlineCommentAnywhere:
+		return l.prefix(i) > i
+	case InlineCommentWhitespace:
+		return i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t') && l.prefix(i+1) > i+1
+	}
+	return false
+}
+
+// delimiter returns the end of the key/value delimiter starting at i, or i if
+// there is no delimiter at i.
+func (l *lexer) delimiter(i int) int {
+	if i >= len(l.src) {
+		return i
+	}
+	switch l.src[i] {
+	case '=':
+		if l.eq {
+			return i + 1
+		}
+		return i
+	case ':':
+		if l.colon {
+			return i + 1
+		}
+		return i
+	}
+
+	// whitespace, optionally followed by a delimiter
+	j := l.spaces(i)
+	if j == i || !l.space {
+		return i
+	}
+	switch {
+	case j < len(l.src) && (l.src[j] == '=' && l.eq || l.src[j] == ':' && l.colon):
+		return j + 1
+	case j == len(l.src), l.lineEnd(j) > j, l.prefix(j) > j:
+		return i
+	}
+	return j
+}
+
+// scanLine scans the line starting at i, returning the Line and the end of
+// the line, or false if the line could not be scanned.
+func (l *lexer) scanLine(i int) (*Line, int, bool) {
+	l.max = i
+	defer func() {
+		if l.max > l.farthest {
+			l.farthest = l.max
+		}
+	}()
+
+	j := l.spaces(i)
+	l.indent = l.src[i:j]
+
+	var item Item
+	k := j
+	switch {
+	case l.prefix(j) > j:
+		item, k = l.comment(j)
+	case j < len(l.src) && l.src[j] == '[':
+		var ok bool
+		if item, k, ok = l.section(j); !ok {
+			return l.invalid(i)
+		}
+	default:
+		item, k = l.keyValuePair(j)
+	}
+
+	e := l.lineEnd(k)
+	if e == k {
+		l.fail(k)
+		return l.invalid(i)
+	}
+	return NewLine(l.pos(i), l.indent, item, l.src[k:e]), e, true
+}
+
+// invalid scans the line starting at i as an Invalid line when parsing with
+// the Lenient option.
+func (l *lexer) invalid(i int) (*Line, int, bool) {
+	if !l.cfg.lenient {
+		return nil, i, false
+	}
+	j := i
+	for j < len(l.src) && l.lineEnd(j) == j {
+		j = l.next(j)
+	}
+	e := l.lineEnd(j)
+	if j == i || e == j {
+		l.fail(j)
+		return nil, i, false
+	}
+	inv := NewInvalid(l.pos(i), l.src[i:j])
+	inv.errPos, inv.cause = l.pos(l.max), l.errAt(l.max)
+	return NewLine(inv.pos, "", inv, l.src[j:e]), e, true
+}
+
+// comment scans the comment starting at i, returning nil if there is no
+// comment at i.
+func (l *lexer) comment(i int) (*Comment, int) {
+	j := l.prefix(i)
+	if j == i {
+		l.fail(i)
+		return nil, i
+	}
+	k := j
+	for k < len(l.src) && l.lineEnd(k) == k {
+		k = l.next(k)
+	}
+	return NewComment(l.pos(i), l.src[i:j], l.src[j:k]), k
+}
+
+// section scans the section header starting at i.
+func (l *lexer) section(i int) (*Section, int, bool) {
+	j := i + 1
+	for j < len(l.src) {
+		if c := l.src[j]; c == '\r' || c == '\n' || c == '[' || c == ']' || l.prefix(j) > j {
+			break
+		}
+		j = l.next(j)
+	}
+	if j == i+1 || j >= len(l.src) || l.src[j] != ']' {
+		l.fail(j)
+		return nil, j, false
+	}
+	k := l.spaces(j + 1)
+	comment, m := l.comment(k)
+	return NewSection(l.pos(i), l.src[i+1:j], l.src[j+1:k], comment), m, true
+}
+
+// keyValuePair scans the key, with or without a value, starting at i,
+// returning nil if there is no key at i.
+func (l *lexer) keyValuePair(i int) (Item, int) {
+	j := i
+	for j < len(l.src) {
+		if c := l.src[j]; c == '\r' || c == '\n' || c == '[' || c == ']' || l.delimiter(j) > j || l.prefix(j) > j {
+			break
+		}
+		j = l.next(j)
+	}
+	if j == i {
+		l.fail(i)
+		return nil, i
+	}
+	pos, key := l.pos(i), l.src[i:j]
+
+	// key only
+	d := l.delimiter(j)
+	if d == j {
+		l.fail(j)
+		k := l.spaces(j)
+		comment, m := l.comment(k)
+		return NewKeyValuePair(pos, key, l.src[j:k], nil, comment), m
+	}
+
+	// value
+	k := l.valueSpace(d)
+	m := l.value(k)
+	value := l.src[k:m]
+	kvp := NewKeyValuePair(pos, key, l.src[d:k], &value, nil)
+	kvp.delim = l.src[j:d]
+
+	// continuation lines
+	for last := value; ; {
+		c, n := l.continuation(m, last)
+		if c == nil {
+			break
+		}
+		kvp.conts = append(kvp.conts, c)
+		last, m = c.value, n
+	}
+
+	kvp.comment, m = l.comment(m)
+	return kvp, m
+}
+
+// valueSpace returns the end of the whitespace preceding a value starting at
+// i, which does not include the start of an inline comment.
+func (l *lexer) valueSpace(i int) int {
+	for i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t') && !l.inlineComment(i) {
+		i++
+	}
+	return i
+}
+
+// value returns the end of the quoted or simple value starting at i.
+func (l *lexer) value(i int) int {
+	if j, ok := l.quotedValue(i); ok {
+		return j
+	}
+
+	// simple value, ending at a line ending or inline comment, and
+	// including a single trailing space
+	j := i
+	for j < len(l.src) && l.src[j] != '\r' && l.src[j] != '\n' && !l.inlineComment(j) {
+		j = l.next(j)
+	}
+	if j < len(l.src) && (l.src[j] == ' ' || l.src[j] == '\t') {
+		j++
+	}
+	l.fail(j)
+	return j
+}
+
+// quotedValue returns the end of the quoted value starting at i, including
+// any trailing whitespace, or false if there is no valid quoted value at i.
+func (l *lexer) quotedValue(i int) (int, bool) {
+	if i >= len(l.src) || l.src[i] != '"' {
+		return i, false
+	}
+	for j := i + 1; j < len(l.src); {
+		switch l.src[j] {
+		case '"':
+			return l.spaces(j + 1), true
+		case '\\':
+			n := escapeLen(l.src[j+1:])
+			if n == 0 {
+				// the escape may be completed by more data
+				l.short = escapePrefix(l.src[j+1:])
+				l.fail(j + 1)
+				return j, false
+			}
+			j += 1 + n
+		default:
+			j = l.next(j)
+		}
+	}
+	l.short = true
+	return len(l.src), false
+}
+
+// escapeLen returns the length of the escape sequence (following a '\') at
+// the start of s, or 0 if s does not start with a valid escape sequence.
+func escapeLen(s string) int {
+	if s == "" {
+		return 0
+	}
+	switch s[0] {
+	case '\\', '/', 'b', 'f', 'n', 'r', 't', '"':
+		return 1
+	case 'u':
+		if len(s) < 5 {
+			return 0
+		}
+		for i := 1; i < 5; i++ {
+			if !isHexDigit(s[i]) {
+				return 0
+			}
+		}
+		return 5
+	}
+	return 0
+}
+
+// escapePrefix returns whether or not s is the start of an escape sequence
+// (following a '\') that is cut off by the end of s.
+func escapePrefix(s string) bool {
+	if s == "" {
+		return true
+	}
+	if s[0] != 'u' || len(s) >= 5 {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isHexDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHexDigit returns whether or not c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// continuation scans the continuation of the value last, starting with the
+// line ending at i, returning nil if the value is not continued.
+func (l *lexer) continuation(i int, last string) (*ContinuationLine, int) {
+	switch l.cfg.continuation {
+	case BackslashContinuation:
+		if !strings.HasSuffix(last, `\`) {
+			return nil, i
+		}
+	case IndentContinuation:
+	default:
+		return nil, i
+	}
+
+	j := l.lineEnd(i)
+	if j == i {
+		return nil, i
+	}
+	k := l.valueSpace(j)
+	m := l.value(k)
+	ws, value := l.src[j:k], l.src[k:m]
+	if l.cfg.continuation == IndentContinuation && (len(ws) <= len(l.indent) || strings.TrimSpace(value) == "") {
+		return nil, i
+	}
+
+	// must be followed by a comment or line ending
+	if n := l.prefix(m); n > m {
+		for n < len(l.src) && l.lineEnd(n) == n {
+			n = l.next(n)
+		}
+		if l.lineEnd(n) == n {
+			return nil, i
+		}
+	} else if l.lineEnd(m) == m {
+		return nil, i
+	}
+	return NewContinuationLine(l.pos(i), l.src[i:j], ws, value), m
+}
+
+// parse parses src into a File, returning the position of the end of src,
+// or the position of the error when src could not be parsed.
+func parse(src string, cfg *config) (*File, position, error) {
+	l := newLexer(src, cfg)
+	var lines []*Line
+	var c counts
+	for i := 0; i < len(src); {
+		line, end, ok := l.scanLine(i)
+		if !ok {
+			i, err := l.err()
+			return nil, l.pos(i), err
+		}
+		if err := c.add(cfg, line.item); err != nil {
+			return nil, line.pos, err
+		}
+		lines, i = append(lines, line), end
+	}
+	return newFile(lines, cfg), l.pos(len(src)), nil
+}
+
+// newFile creates a File for lines, using the parse configuration.
+func newFile(lines []*Line, cfg *config) *File {
+	f := NewFile(lines)
+	f.Continuation = cfg.continuation
+	f.Delimiters = cfg.delimiters
+	f.CommentPrefixes = cfg.commentPrefixes
+	f.InlineComments = cfg.inlineComments
+	f.SetCaseMode(cfg.caseMode)
+	return f
+}
@@ -0,0 +1,306 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+const complexString = `   ;comment1
+	defkey1= defvalue1
+	defkey2=
+defkey3 = defvalue3 #comment2
+defkey4
+defkey5 ; comment3
+defkey6 # comment4
+
+  [   section1   ] #seccomment1
+      key1 = value1
+key2 = value2# comment3
+
+          # comment4
+key3
+key4 ; comment5
+
+[section2 ]
+
+[SECTION3] #seccomment2
+s3key1 =
+s3key2 = s3value2      # comment5
+
+[ 毚饯襃ブみょ ]
+䥵妦飌ぞ盯 = 覎びゅフォ駧橜 槞㨣
+
+[test2]
+test=foo
+[test3]
+
+test=bar
+
+`
+
+// largeString returns a generated file with n sections of 100 keys.
+func largeString(n int) string {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "[section%d]\n", i)
+		for j := 0; j < 100; j++ {
+			fmt.Fprintf(&buf, "key%d = value%d\n", j, j)
+		}
+	}
+	return buf.String()
+}
+
+func TestLexerLineEndings(t *testing.T) {
+	d0 := "a = 1\r\n[s] ; c\r\nk = \"x\" ; c\r\n\r\nflag\nb = 2\r\n"
+	f, _, err := Parse("", []byte(d0))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	les := []string{"\r\n", "\r\n", "\r\n", "\r\n", "\n", "\r\n"}
+	if len(f.lines) != len(les) {
+		t.Fatalf("expected %d lines, got: %d", len(les), len(f.lines))
+	}
+	for i, l := range f.lines {
+		if l.le != les[i] {
+			t.Errorf("line %d expected line ending %q, got: %q", i, les[i], l.le)
+		}
+	}
+	for key, exp := range map[string]string{"a": "1", "s.k": "x", "s.b": "2"} {
+		if v := f.GetKey(key); v != exp {
+			t.Errorf("%s expected %q, got: %q", key, exp, v)
+		}
+	}
+	if v := f.GetSection("s").KeyComment("k"); v != "c" {
+		t.Errorf("expected comment %q, got: %q", "c", v)
+	}
+	if d0 != f.String() {
+		t.Errorf("expected %q, got: %q", d0, f.String())
+	}
+
+	// a lone '\r' is not a line ending
+	if _, pos, err := Parse("", []byte("a = 1\rb = 2\n")); !errors.Is(err, ErrSyntax) || pos.Col != 6 {
+		t.Errorf("expected ErrSyntax at 1:6, got: %v at %v", err, pos)
+	}
+}
+
+func TestLexerQuotedValues(t *testing.T) {
+	tests := []struct {
+		s, exp string
+	}{
+		{`"plain"`, "plain"},
+		{`""`, ""},
+		{`"a;b#c"`, "a;b#c"},
+		{`"\"quoted\""`, `"quoted"`},
+		{`"\\ \/ \b\f\n\r\t"`, "\\ / \b\f\n\r\t"},
+		{`"éé"`, "éé"},
+		{`"😀"`, "😀"},
+		{`"  padded  "  `, "  padded  "},
+		{"\"multi\nline\"", "multi\nline"},
+
+		// not quoted values
+		{`"a\x"`, `"a\x"`},
+		{`"a\u12"`, `"a\u12"`},
+		{`"a`, `"a`},
+	}
+	for i, test := range tests {
+		f, _, err := Parse("", []byte("k = "+test.s+" ; comment\n"))
+		if err != nil {
+			t.Errorf("test %d expected no error, got: %v", i, err)
+			continue
+		}
+		if v := f.GetKey("k"); v != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, v)
+		}
+		if v := f.GetSection("").KeyComment("k"); v != "comment" {
+			t.Errorf("test %d expected comment, got: %q", i, v)
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		opts []Option
+		line int
+		col  int
+	}{
+		{"[s\n", nil, 1, 3},
+		{"[]\n", nil, 1, 2},
+		{"[s]x\n", nil, 1, 4},
+		{"[s;]\n", nil, 1, 3},
+		{"k]=v\n", nil, 1, 2},
+		{"=v\n", nil, 1, 1},
+		{"k = v\n[s]]\n", nil, 2, 4},
+		{"k[x] = 1\n", nil, 1, 2},
+		{"k = 1\n  [s\n", nil, 2, 5},
+		{"a = 1\r\n\r[s]\n", nil, 2, 1},
+		{"䥵妦]\n", nil, 1, 3},
+		{"[毚饯]\n䥵 = 1\n[x\n", nil, 3, 3},
+		{"k = \"a\" b ; c\n", nil, 1, 9},
+	}
+	for i, test := range tests {
+		f, pos, err := Parse("", []byte(test.s), test.opts...)
+		switch {
+		case f != nil || !errors.Is(err, ErrSyntax):
+			t.Errorf("test %d expected ErrSyntax, got: %v", i, err)
+		case pos.Line != test.line || pos.Col != test.col:
+			t.Errorf("test %d expected error at %d:%d, got: %v", i, test.line, test.col, pos)
+		}
+
+		// lenient parsing reports the same position
+		_, _, err = Parse("", []byte(test.s), append(test.opts, Lenient(true))...)
+		var errs ErrorList
+		switch {
+		case !errors.As(err, &errs) || len(errs) == 0:
+			t.Errorf("test %d expected ErrorList, got: %v", i, err)
+		case errs[0].Pos.Line != test.line || errs[0].Pos.Col != test.col:
+			t.Errorf("test %d expected lenient error at %d:%d, got: %v", i, test.line, test.col, errs[0].Pos)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// lexerTests are the inputs and options for the lines and errors recorded in
+// testdata/lexer.golden.
+//
+// The recorded lines were first generated by the Pigeon grammar that the
+// lexer replaced, except for the inputs with CRLF line endings, which the
+// grammar could not parse.
+var lexerTests = []struct {
+	name string
+	s    string
+	opts []Option
+}{
+	{"complex", complexString, nil},
+	{"large", largeString(2), nil},
+	{"quoted", "a=1\n[s]\nk = \"x;y\" ; c\nq = \"a\\\"b\\u00e9\"\n", nil},
+	{"inline-whitespace", "[s]\nk = a;b #c\nu = http://x/#a ; c\n", []Option{InlineComments(InlineCommentWhitespace)}},
+	{"inline-none", "[s]\nk = a;b #c\n", []Option{InlineComments(InlineCommentNone)}},
+	{"delimiters", "[s]\nk: v\nk2 = v2\nk3 v3\nflag\n", []Option{Delimiters(":= ")}},
+	{"comment-prefixes", "[s]\nk = v // c\n// c\n", []Option{CommentPrefixes("//", "#")}},
+	{"backslash", "[s]\nk = a \\\n  b \\\n  c ; c\nn = 1\n", []Option{Continuation(BackslashContinuation)}},
+	{"indent", "[s]\nk = a\n  b\n\n  c\n\tn = 1\n", []Option{Continuation(IndentContinuation)}},
+	{"lenient", "k=1\n[bad\n]x\n[s]\nk=\"a\n", []Option{Lenient(true)}},
+	{"crlf", "a = 1\r\n[s] ; c\r\nk = \"x\" ; c\r\n\r\nflag\nb = 2\r\n", nil},
+	{"crlf-indent", "[s]\r\nk = a\r\n  b\r\nn = 1\r\n", []Option{Continuation(IndentContinuation)}},
+	{"error-section", "[s\n", nil},
+	{"error-after-quote", "k = \"a\" b\n", nil},
+	{"error-after-quote-comment", "k = \"a\" b ; c\n", nil},
+	{"invalid-escape", "k = \"a\\x\" ; c\n", nil},
+	{"error-continuation", "[s]\nk = a \\\n  [x\n", []Option{Continuation(BackslashContinuation)}},
+}
+
+// lexerLines returns a description of each of the lines parsed by the lexer.
+func lexerLines(lines []*Line) []string {
+	comment := func(c *Comment) string {
+		if c == nil {
+			return "<nil>"
+		}
+		return fmt.Sprintf("%d %q %q", c.pos.offset, c.cs, c.comment)
+	}
+	var v []string
+	for _, l := range lines {
+		var item string
+		switch x := l.item.(type) {
+		case *Comment:
+			item = "comment " + comment(x)
+		case *Section:
+			item = fmt.Sprintf("section %d %q %q %s", x.pos.offset, x.name, x.ws, comment(x.comment))
+		case *KeyValuePair:
+			value := "<nil>"
+			if x.value != nil {
+				value = fmt.Sprintf("%q", *x.value)
+			}
+			item = fmt.Sprintf("key %d %q %q %q %s %s", x.pos.offset, x.key, x.delim, x.ws, value, comment(x.comment))
+			for _, c := range x.conts {
+				item += fmt.Sprintf(" cont %d %q %q %q", c.pos.offset, c.le, c.ws, c.value)
+			}
+		case *Invalid:
+			item = fmt.Sprintf("invalid %d %q", x.pos.offset, x.text)
+		}
+		v = append(v, fmt.Sprintf("%d %q %s %q", l.pos.offset, l.ws, item, l.le))
+	}
+	return v
+}
+
+// readGolden returns the output of each test in the golden file name.
+func readGolden(name string) (map[string]string, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	var test string
+	for _, line := range strings.SplitAfter(string(buf), "\n") {
+		if strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --\n") {
+			test = strings.TrimSuffix(strings.TrimPrefix(line, "-- "), " --\n")
+			continue
+		}
+		m[test] += line
+	}
+	return m, nil
+}
+
+func TestLexerGolden(t *testing.T) {
+	const name = "testdata/lexer.golden"
+	var buf bytes.Buffer
+	out := make(map[string]string)
+	for _, test := range lexerTests {
+		var v string
+		f, pos, err := parse(test.s, newConfig(test.opts...))
+		if err != nil {
+			v = fmt.Sprintf("error %d:%d: %v\n", pos.line, pos.col, err)
+		} else {
+			v = strings.Join(lexerLines(f.lines), "\n") + "\n"
+		}
+		fmt.Fprintf(&buf, "-- %s --\n%s", test.name, v)
+		out[test.name] = v
+	}
+	if *update {
+		if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("could not write %s: %v", name, err)
+		}
+		return
+	}
+
+	exp, err := readGolden(name)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", name, err)
+	}
+	if len(exp) != len(lexerTests) {
+		t.Errorf("expected %d tests in %s, got: %d", len(lexerTests), name, len(exp))
+	}
+	for _, test := range lexerTests {
+		if out[test.name] != exp[test.name] {
+			t.Errorf("%s expected:\n%s\ngot:\n%s", test.name, exp[test.name], out[test.name])
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	tests := []struct {
+		name string
+		s    string
+	}{
+		{"complex", complexString},
+		{"large", largeString(200)},
+	}
+	for _, test := range tests {
+		buf := []byte(test.s)
+		b.Run(test.name, func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := Parse("", buf); err != nil {
+					b.Fatalf("expected no error, got: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrLimitExceeded is the error wrapped by a LimitError.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limit is a limit on the size of parsed ini data.
+type Limit int
+
+// Limit values.
+const (
+	// LimitBytes is the limit on the size of the data, set by the MaxBytes
+	// option.
+	LimitBytes Limit = iota + 1
+
+	// LimitLineLength is the limit on the length of a line, set by the
+	// MaxLineLength option.
+	LimitLineLength
+
+	// LimitSections is the limit on the number of sections, set by the
+	// MaxSections option.
+	LimitSections
+
+	// LimitKeys is the limit on the number of keys, set by the MaxKeys
+	// option.
+	LimitKeys
+)
+
+// String satisfies the fmt.Stringer interface.
+func (l Limit) String() string {
+	switch l {
+	case LimitBytes:
+		return "size"
+	case LimitLineLength:
+		return "line length"
+	case LimitSections:
+		return "section count"
+	case LimitKeys:
+		return "key count"
+	}
+	return "unknown"
+}
+
+// LimitError is the error returned when ini data exceeds a limit set by a
+// parse option.
+type LimitError struct {
+	Limit Limit // exceeded limit
+	Max   int   // maximum allowed
+}
+
+// Error satisfies the error interface.
+func (err *LimitError) Error() string {
+	return fmt.Sprintf("%v limit of %d exceeded", err.Limit, err.Max)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (err *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// limits are the limits set by parse options. A zero limit is unlimited.
+type limits struct {
+	bytes, lineLength, sections, keys int
+}
+
+// MaxBytes creates an Option to limit the size of the data, in bytes, that
+// is read and parsed. A zero (or negative) n is unlimited.
+func MaxBytes(n int) Option {
+	return func(cfg *config) {
+		cfg.limits.bytes = n
+	}
+}
+
+// MaxLineLength creates an Option to limit the length of each line, in
+// bytes, not including the line ending. Continuation lines are limited
+// separately. A zero (or negative) n is unlimited.
+func MaxLineLength(n int) Option {
+	return func(cfg *config) {
+		cfg.limits.lineLength = n
+	}
+}
+
+// MaxSections creates an Option to limit the number of section headers. A
+// zero (or negative) n is unlimited.
+func MaxSections(n int) Option {
+	return func(cfg *config) {
+		cfg.limits.sections = n
+	}
+}
+
+// MaxKeys creates an Option to limit the total number of keys, in all
+// sections. A zero (or negative) n is unlimited.
+func MaxKeys(n int) Option {
+	return func(cfg *config) {
+		cfg.limits.keys = n
+	}
+}
+
+// AllowInvalidUTF8 creates an Option to accept data that is not valid UTF-8,
+// instead of failing with ErrInvalidEncoding. Invalid bytes are preserved as
+// is in the parsed section names, keys and values.
+func AllowInvalidUTF8(b bool) Option {
+	return func(cfg *config) {
+		cfg.allowInvalidUTF8 = b
+	}
+}
+
+// ReadAll reads the data from r until EOF, returning a *LimitError when the
+// data exceeds the size set by the MaxBytes option.
+func ReadAll(r io.Reader, opts ...Option) ([]byte, error) {
+	n := newConfig(opts...).limits.bytes
+	if n <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(r, int64(n)+1))
+	switch {
+	case err != nil:
+		return nil, err
+	case len(b) > n:
+		return nil, &LimitError{Limit: LimitBytes, Max: n}
+	}
+	return b, nil
+}
+
+// longLine returns the offset of the first byte exceeding the line length
+// limit in s, or -1 if no line in s exceeds the limit.
+func (cfg *config) longLine(s string) int {
+	n := cfg.limits.lineLength
+	if n <= 0 {
+		return -1
+	}
+	for i := 0; i < len(s); {
+		end := len(s)
+		if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+			end = i + j
+		}
+		if len(strings.TrimSuffix(s[i:end], "\r")) > n {
+			return i + n
+		}
+		i = end + 1
+	}
+	return -1
+}
+
+// counts are the number of sections and keys scanned.
+type counts struct {
+	sections, keys int
+}
+
+// add counts item, returning a *LimitError if the count exceeds its limit.
+func (c *counts) add(cfg *config, item Item) error {
+	switch item.(type) {
+	case *Section:
+		if c.sections++; cfg.limits.sections > 0 && c.sections > cfg.limits.sections {
+			return &LimitError{Limit: LimitSections, Max: cfg.limits.sections}
+		}
+	case *KeyValuePair:
+		if c.keys++; cfg.limits.keys > 0 && c.keys > cfg.limits.keys {
+			return &LimitError{Limit: LimitKeys, Max: cfg.limits.keys}
+		}
+	}
+	return nil
+}
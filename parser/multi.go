@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// valueMatcher returns a func that matches a value against valueRegex, in the
+// same way as git config's value pattern. An empty valueRegex matches all
+// values, and a valueRegex prefixed with '!' matches values that do not
+// match the remainder of the expression.
+func valueMatcher(valueRegex string) (func(string) bool, error) {
+	if valueRegex == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	not := strings.HasPrefix(valueRegex, "!")
+	re, err := regexp.Compile(strings.TrimPrefix(valueRegex, "!"))
+	if err != nil {
+		return nil, err
+	}
+	return func(v string) bool {
+		return re.MatchString(v) != not
+	}, nil
+}
+
+// keyLines returns the line indexes of all occurrences of key in Section.
+func (s *Section) keyLines(key string) []int {
+	var idxs []int
+	cur := s.file.sections[0]
+	for idx, l := range s.file.lines {
+		switch v := l.item.(type) {
+		case *Section:
+			cur = v
+		case *KeyValuePair:
+			if cur == s && s.file.KeyCompFunc(v.key, key) {
+				idxs = append(idxs, idx)
+			}
+		}
+	}
+	return idxs
+}
+
+// value returns the value for the KeyValuePair on line idx, unquoting it in
+// the same way as Get.
+func (s *Section) value(idx int) string {
+	kvp := s.file.lines[idx].item.(*KeyValuePair)
+	if kvp.value == nil {
+		return ""
+	}
+	return s.file.value(kvp)
+}
+
+// GetAll returns the values for all occurrences of key in Section, in the
+// order they are in the File.
+//
+// Values are unquoted in the same way as Get.
+func (s *Section) GetAll(key string) []string {
+	var values []string
+	for _, idx := range s.keyLines(key) {
+		values = append(values, s.value(idx))
+	}
+	return values
+}
+
+// AddValue adds a new line for key with the provided value after the last
+// occurrence of key, without altering any existing values. If the key does
+// not exist, then it is added to the end of the Section.
+//
+// Passes key through KeyManipFunc, and quotes the value as with SetKey.
+// Returns ErrInvalidKey if key is not valid.
+func (s *Section) AddValue(key, value string) error {
+	key = s.file.KeyManipFunc(key)
+	if err := s.file.validKey(key); err != nil {
+		return err
+	}
+	value = s.file.encodeValue(value)
+
+	idxs := s.keyLines(key)
+	if len(idxs) == 0 {
+		return s.setKeyValue(key, value)
+	}
+
+	// insert after last occurrence
+	last := s.file.lines[idxs[len(idxs)-1]]
+	k := s.file.newKeyValuePair(key, &value)
+	line := NewLine(position{}, last.ws, k, last.le)
+	pos := idxs[len(idxs)-1] + 1
+	s.file.lines = slices.Insert(s.file.lines, pos, line)
+	s.resetKeys()
+	s.file.indexKey(s, k)
+	return nil
+}
+
+// ReplaceAll replaces all occurrences of key having a value matching
+// valueRegex with a single line for key with the provided value, placed at
+// the first matching occurrence. If no occurrences match, then the key is
+// added to the end of the Section.
+//
+// An empty valueRegex matches all values, and a valueRegex prefixed with '!'
+// matches values not matching the expression. Passes key through
+// KeyManipFunc, and quotes the value as with SetKey. See SetKeyErr for the
+// errors returned.
+func (s *Section) ReplaceAll(key, value, valueRegex string) error {
+	key = s.file.KeyManipFunc(key)
+	if err := s.file.validKey(key); err != nil {
+		return err
+	}
+	match, err := valueMatcher(valueRegex)
+	if err != nil {
+		return err
+	}
+
+	// find matching
+	var idxs []int
+	for _, idx := range s.keyLines(key) {
+		if match(s.value(idx)) {
+			idxs = append(idxs, idx)
+		}
+	}
+	if len(idxs) == 0 {
+		return s.AddValue(key, value)
+	}
+
+	// replace first, remove remaining
+	value = s.file.encodeValue(value)
+	if err := s.file.setValue(s.file.lines[idxs[0]].item.(*KeyValuePair), &value); err != nil {
+		return err
+	}
+	s.removeLines(idxs[1:])
+	return nil
+}
+
+// UnsetAll removes all occurrences of key having a value matching
+// valueRegex, along with their doc comments.
+//
+// An empty valueRegex matches all values, and a valueRegex prefixed with '!'
+// matches values not matching the expression.
+func (s *Section) UnsetAll(key, valueRegex string) error {
+	match, err := valueMatcher(valueRegex)
+	if err != nil {
+		return err
+	}
+
+	var idxs []int
+	for _, idx := range s.keyLines(key) {
+		if match(s.value(idx)) {
+			idxs = append(idxs, idx)
+		}
+	}
+	s.removeLines(idxs)
+	return nil
+}
+
+// removeLines removes the key lines at idxs (in ascending order) and their
+// doc comments.
+func (s *Section) removeLines(idxs []int) {
+	for i := len(idxs) - 1; i >= 0; i-- {
+		idx := idxs[i]
+		start := s.file.docStart(idx)
+		s.file.lines = append(s.file.lines[:start], s.file.lines[idx+1:]...)
+	}
+	s.resetKeys()
+	s.file.ResetIndex()
+}
+
+// resetKeys rebuilds s.keys from the Section's lines, keeping the keys in
+// the same order as the lines for repeated keys.
+func (s *Section) resetKeys() {
+	s.keys = nil
+	cur := s.file.sections[0]
+	for _, l := range s.file.lines {
+		switch v := l.item.(type) {
+		case *Section:
+			cur = v
+		case *KeyValuePair:
+			if cur == s {
+				s.keys = append(s.keys, v.key)
+			}
+		}
+	}
+}
+
+// GetAll returns the values for all occurrences of a key in File with name
+// in form of section.key.
+//
+// Uses File's NameSplitFunc to split the key. See Section.GetAll.
+func (f *File) GetAll(key string) []string {
+	name, k := f.NameSplitFunc(key)
+	section := f.GetSection(name)
+	if section == nil {
+		return nil
+	}
+	return section.GetAll(k)
+}
+
+// AddValue adds a new value for a key in File with name in form of
+// section.key, after the key's last occurrence.
+//
+// If the section does not exist, then it is created. Uses File's
+// NameSplitFunc to split the key. See Section.AddValue.
+func (f *File) AddValue(key, value string) error {
+	section, k, err := f.keySection(key)
+	if err != nil {
+		return err
+	}
+	return section.AddValue(k, value)
+}
+
+// ReplaceAll replaces all occurrences of a key in File with name in form of
+// section.key having a value matching valueRegex.
+//
+// If the section does not exist, then it is created. Uses File's
+// NameSplitFunc to split the key. See Section.ReplaceAll.
+func (f *File) ReplaceAll(key, value, valueRegex string) error {
+	section, k, err := f.keySection(key)
+	if err != nil {
+		return err
+	}
+	return section.ReplaceAll(k, value, valueRegex)
+}
+
+// UnsetAll removes all occurrences of a key in File with name in form of
+// section.key having a value matching valueRegex.
+//
+// Uses File's NameSplitFunc to split the key. See Section.UnsetAll.
+func (f *File) UnsetAll(key, valueRegex string) error {
+	name, k := f.NameSplitFunc(key)
+	section := f.GetSection(name)
+	if section == nil {
+		return nil
+	}
+	return section.UnsetAll(k, valueRegex)
+}
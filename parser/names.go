@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Name errors.
+var (
+	ErrInvalidSectionName = errors.New("invalid section name")
+	ErrInvalidKey         = errors.New("invalid key")
+	ErrSectionNotFound    = errors.New("section not found")
+	ErrKeyExists          = errors.New("key already exists")
+)
+
+// validSectionName checks that name is parsed as a section name, and can be
+// written to the File and parsed back as the same name.
+//
+// The empty name, and names containing only whitespace, are not valid, as the
+// empty (first) section has no header.
+func (f *File) validSectionName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "\r\n[]") || f.containsCommentPrefix(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidSectionName)
+	}
+	return nil
+}
+
+// validKey checks that key is parsed as a key, and can be written to the File
+// and parsed back as the same key.
+func (f *File) validKey(key string) error {
+	switch {
+	case key == "",
+		// leading whitespace is parsed as the line's whitespace
+		key[0] == ' ' || key[0] == '\t',
+		strings.ContainsAny(key, "=\r\n[]"),
+		f.containsCommentPrefix(key),
+		strings.IndexFunc(key, func(r rune) bool {
+			return isDelimiter(f.Delimiters, string(r))
+		}) != -1:
+		return fmt.Errorf("%q: %w", key, ErrInvalidKey)
+	}
+	return nil
+}
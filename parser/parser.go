@@ -1,13 +1,15 @@
-// Package parser is a Pigeon-based ini file parser.
+// Package parser is an ini file parser.
 //
 // Please see http://godoc.org/github.com/kenshaw/ini for the frontend package.
 package parser
 
-//go:generate ./gen.sh
-
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -23,18 +25,267 @@ var (
 	// style keys.
 	DefaultNameKeySeparator = "."
 
-	// last position
-	lastPosition position
+	// DefaultCommentSeparator is the default comment separator for comments
+	// added to a file that does not contain any comments.
+	DefaultCommentSeparator = ";"
+
+	// DefaultCommentPrefixes are the default comment prefixes, used when
+	// parsing without the CommentPrefixes option.
+	DefaultCommentPrefixes = []string{";", "#"}
 
-	// last text
-	lastText string
+	// DefaultDelimiter is the default key/value delimiter, used when parsing
+	// without the Delimiters option.
+	DefaultDelimiter = "="
+
+	// FlagValue is the value reported for key-only entries (ie, keys without
+	// a value, such as "skip-name-resolve") by GetMap and related funcs.
+	FlagValue = "true"
 )
 
+// Position is a position in parsed ini data.
+type Position struct {
+	Line   int // line number, starting at 1
+	Col    int // column number (in characters), starting at 1
+	Offset int // byte offset, starting at 0
+}
+
+// String satisfies the fmt.Stringer interface.
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// position records a position in the parsed data.
+type position struct {
+	line, col, offset int
+}
+
+// Position returns the exported Position for p.
+func (p position) Position() Position {
+	return Position{
+		Line:   p.line,
+		Col:    p.col,
+		Offset: p.offset,
+	}
+}
+
+// positionAt returns the Position of offset in b.
+func positionAt(b []byte, offset int) Position {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	line, start := 1, 0
+	for i := 0; i < offset; i++ {
+		if b[i] == '\n' {
+			line, start = line+1, i+1
+		}
+	}
+	return Position{
+		Line:   line,
+		Col:    utf8.RuneCount(b[start:offset]) + 1,
+		Offset: offset,
+	}
+}
+
+// Option is a parse option.
+type Option func(*config)
+
+// Lenient creates an Option to enable or disable the lenient parse mode.
+//
+// When enabled, lines that cannot be parsed are preserved verbatim as Invalid
+// items, instead of failing the parse. Parse returns the File along with an
+// ErrorList describing each Invalid line.
+func Lenient(b bool) Option {
+	return func(cfg *config) {
+		cfg.lenient = b
+	}
+}
+
+// ContinuationStyle is the style of continuation lines used for values that
+// span several physical lines.
+type ContinuationStyle int
+
+// ContinuationStyle values.
+const (
+	// NoContinuation disables continuation lines.
+	NoContinuation ContinuationStyle = iota
+
+	// BackslashContinuation continues a value ending with '\' on the next
+	// line (ie, "key = a \" followed by "b"). The logical value is the
+	// concatenation of the lines, without the '\' and the leading whitespace
+	// of the continuation lines.
+	BackslashContinuation
+
+	// IndentContinuation continues a value on the following non-blank lines
+	// that are indented more than the key (ie, Python's configparser style).
+	// The logical value is the lines, trimmed and joined by '\n'.
+	IndentContinuation
+)
+
+// Continuation creates an Option to set the continuation style used when
+// parsing values spanning several lines.
+//
+// The style is recorded on the parsed File, and is used when setting values
+// containing line breaks.
+func Continuation(style ContinuationStyle) Option {
+	return func(cfg *config) {
+		cfg.continuation = style
+	}
+}
+
+// Delimiters creates an Option to set the delimiters accepted between a key
+// and its value when parsing, which may be any of '=', ':' and ' ' (ie,
+// Delimiters("=:") for Python's configparser). A space or tab in delims
+// accepts whitespace as a delimiter, as used by Java .properties files.
+//
+// The delimiters are recorded on the parsed File. An empty delims uses
+// DefaultDelimiter.
+func Delimiters(delims string) Option {
+	return func(cfg *config) {
+		cfg.delimiters = delims
+	}
+}
+
+// CommentPrefixes creates an Option to set the prefixes starting a comment
+// when parsing (ie, "!" for .properties files, or "//"). Prefixes may be up to
+// three characters long.
+//
+// The prefixes are recorded on the parsed File. Empty prefixes uses
+// DefaultCommentPrefixes.
+func CommentPrefixes(prefixes ...string) Option {
+	return func(cfg *config) {
+		cfg.commentPrefixes = prefixes
+	}
+}
+
+// InlineCommentPolicy is the policy for comments following a key's value on
+// the same line.
+type InlineCommentPolicy int
+
+// InlineCommentPolicy values.
+const (
+	// InlineCommentAnywhere starts an inline comment at any comment prefix
+	// following the key (ie, "key = a;b" has the value "a").
+	InlineCommentAnywhere InlineCommentPolicy = iota
+
+	// InlineCommentWhitespace starts an inline comment only at a comment
+	// prefix preceded by whitespace, as in Python's configparser (ie,
+	// "url = http://x/#a ; comment" has the value "http://x/#a").
+	InlineCommentWhitespace
+
+	// InlineCommentNone disables inline comments, so that comment prefixes
+	// following the key are part of the value.
+	InlineCommentNone
+)
+
+// InlineComments creates an Option to set the inline comment policy used when
+// parsing. The policy is recorded on the parsed File.
+//
+// The policy applies to key lines only, as section names cannot contain
+// comment prefixes.
+func InlineComments(policy InlineCommentPolicy) Option {
+	return func(cfg *config) {
+		cfg.inlineComments = policy
+	}
+}
+
+// Error is a parse error at a position in ini data.
+type Error struct {
+	Pos Position // position of the error
+	Err error    // underlying error
+}
+
+// Error satisfies the error interface.
+func (err *Error) Error() string {
+	return fmt.Sprintf("line %d:%d: %v", err.Pos.Line, err.Pos.Col, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// ErrorList is a list of parse errors.
+type ErrorList []*Error
+
+// Error satisfies the error interface.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Parse parses the data from b using filename as information in the error
+// messages.
+//
+// Returns the parsed File and the position of the last match. If an error is
+// encountered, the returned position is where the error occurred.
+//
+// When parsing with the Lenient option, the File is returned along with an
+// ErrorList when any line could not be parsed.
+func Parse(filename string, b []byte, opts ...Option) (*File, Position, error) {
+	cfg := newConfig(opts...)
+	if n := cfg.limits.bytes; n > 0 && len(b) > n {
+		return nil, positionAt(b, n), &LimitError{Limit: LimitBytes, Max: n}
+	}
+	src := string(b)
+	if i := invalidUTF8(src); i >= 0 && !cfg.allowInvalidUTF8 {
+		return nil, positionAt(b, i), ErrInvalidEncoding
+	}
+	if i := cfg.longLine(src); i >= 0 {
+		return nil, positionAt(b, i), &LimitError{Limit: LimitLineLength, Max: cfg.limits.lineLength}
+	}
+
+	f, pos, err := parse(src, cfg)
+	if err != nil {
+		return nil, pos.Position(), err
+	}
+
+	// collect errors for invalid lines
+	var errs ErrorList
+	for _, l := range f.lines {
+		if inv, ok := l.item.(*Invalid); ok {
+			errs = append(errs, inv.err())
+		}
+	}
+	if len(errs) != 0 {
+		return f, pos.Position(), errs
+	}
+	return f, pos.Position(), nil
+}
+
+// ParseReader parses the data from r using filename as information in the
+// error messages.
+//
+// When parsing with the MaxBytes option, no more than the limit (plus one
+// byte) is read from r.
+func ParseReader(filename string, r io.Reader, opts ...Option) (*File, Position, error) {
+	b, err := ReadAll(r, opts...)
+	if err != nil {
+		return nil, Position{}, err
+	}
+	return Parse(filename, b, opts...)
+}
+
+// ParseFile parses the file identified by filename.
+func ParseFile(filename string, opts ...Option) (*File, Position, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, Position{}, err
+	}
+	defer f.Close()
+	return ParseReader(filename, f, opts...)
+}
+
 // SectionManipFunc manipulates a Section name.
 //
 // This function is used when a section name is created or altered.
 //
-// Override on a per-File basis by setting File.SectionManipFunc.
+// Override on a per-File basis by setting File.SectionManipFunc, or by using
+// File.SetCaseMode.
 func SectionManipFunc(name string) string {
 	return strings.TrimSpace(strings.ToLower(name))
 }
@@ -55,7 +306,8 @@ func SectionNameFunc(name string) string {
 //
 // This function is used when a key is created or altered.
 //
-// Override on a per-File basis by setting File.KeyManipFunc.
+// Override on a per-File basis by setting File.KeyManipFunc, or by using
+// File.SetCaseMode.
 func KeyManipFunc(key string) string {
 	return strings.TrimSpace(strings.ToLower(key))
 }
@@ -98,11 +350,6 @@ func ValueManipFunc(value string) string {
 	return strings.TrimSpace(value)
 }
 
-// LastError returns the last error encountered (if any) during parsing.
-func LastError() error {
-	return fmt.Errorf("error on line %d:%d near '%s'", lastPosition.line, lastPosition.col, lastText)
-}
-
 // Item is the shared interface for Comment, Section, and KeyValuePair.
 type Item interface {
 	String() string
@@ -138,6 +385,45 @@ func (l Line) String() string {
 	return fmt.Sprintf("%s%s%s", l.ws, item, l.le)
 }
 
+// Invalid is a line in a File that could not be parsed.
+//
+// Invalid lines are only created when parsing with the Lenient option, and
+// are preserved verbatim.
+type Invalid struct {
+	pos position
+
+	text string // raw text of the line
+
+	errPos position // position of the parse error
+	cause  error    // parse error
+}
+
+// NewInvalid creates a new Invalid line item.
+func NewInvalid(pos position, text string) *Invalid {
+	return &Invalid{
+		pos: pos,
+
+		text: text,
+	}
+}
+
+// String returns the raw text of the invalid line.
+func (inv Invalid) String() string {
+	return inv.text
+}
+
+// err returns the parse error for the invalid line.
+func (inv *Invalid) err() *Error {
+	err := inv.cause
+	if err == nil {
+		err = errors.New("invalid line")
+	}
+	return &Error{
+		Pos: inv.errPos.Position(),
+		Err: err,
+	}
+}
+
 // Comment in a File.
 type Comment struct {
 	pos position
@@ -166,8 +452,10 @@ type KeyValuePair struct {
 	pos position
 
 	key   string
+	delim string
 	ws    string
 	value *string
+	conts []*ContinuationLine
 
 	comment *Comment
 }
@@ -178,6 +466,7 @@ func NewKeyValuePair(pos position, key, ws string, value *string, comment *Comme
 		pos: pos,
 
 		key:     key,
+		delim:   DefaultDelimiter,
 		ws:      ws,
 		value:   value,
 		comment: comment,
@@ -193,5 +482,35 @@ func (kvp KeyValuePair) String() string {
 	if kvp.value == nil {
 		return fmt.Sprintf("%s%s%s", kvp.key, kvp.ws, comment)
 	}
-	return fmt.Sprintf("%s=%s%s%s", kvp.key, kvp.ws, *kvp.value, comment)
+	var conts string
+	for _, c := range kvp.conts {
+		conts += c.String()
+	}
+	return fmt.Sprintf("%s%s%s%s%s%s", kvp.key, kvp.delim, kvp.ws, *kvp.value, conts, comment)
+}
+
+// ContinuationLine is a continuation of a key's value on a following line.
+type ContinuationLine struct {
+	pos position
+
+	le    string
+	ws    string
+	value string
+}
+
+// NewContinuationLine creates a new continuation line.
+func NewContinuationLine(pos position, le, ws, value string) *ContinuationLine {
+	return &ContinuationLine{
+		pos: pos,
+
+		le:    le,
+		ws:    ws,
+		value: value,
+	}
+}
+
+// String returns a formatted continuation line, starting with the line
+// ending of the previous line.
+func (c ContinuationLine) String() string {
+	return fmt.Sprintf("%s%s%s", c.le, c.ws, c.value)
 }
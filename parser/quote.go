@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalidQuotedValue is the error returned by Unquote when a value is not
+// a valid quoted value.
+var ErrInvalidQuotedValue = errors.New("invalid quoted value")
+
+// Quote returns value as a quoted value, escaping quotes, backslashes and
+// control characters.
+func Quote(value string) string {
+	return quote(value)
+}
+
+// Unquote decodes the quoted value s, as returned by Section.GetRaw.
+//
+// Returns ErrInvalidQuotedValue if s is not a valid quoted value.
+func Unquote(s string) (string, error) {
+	v, ok := unquote(s)
+	if !ok {
+		return "", ErrInvalidQuotedValue
+	}
+	return v, nil
+}
+
+// decodeValue returns the value for the raw value, unquoting quoted values
+// and passing other values through ValueManipFunc.
+func (f *File) decodeValue(raw string) string {
+	if v, ok := unquote(raw); ok {
+		return v
+	}
+	return f.ValueManipFunc(raw)
+}
+
+// encodeValue returns the raw value written for value. Values that must be
+// quoted are quoted as-is, preserving any leading and trailing whitespace,
+// while other values are passed through ValueManipFunc (and quoted if the
+// result must be quoted).
+func (f *File) encodeValue(value string) string {
+	if !f.needsQuote(value) {
+		value = f.ValueManipFunc(value)
+	}
+	if f.needsQuote(value) {
+		return quote(value)
+	}
+	return value
+}
+
+// needsQuote determines if value must be quoted in order to be parsed back
+// as the same value, using the File's comment prefixes and inline comment
+// policy.
+func (f *File) needsQuote(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	// leading/trailing whitespace or a leading quote
+	switch value[0] {
+	case ' ', '\t', '"':
+		return true
+	}
+	switch value[len(value)-1] {
+	case ' ', '\t':
+		return true
+	case '\\':
+		// continued on the next line
+		if f.Continuation == BackslashContinuation {
+			return true
+		}
+	}
+
+	// line ending characters
+	if strings.ContainsAny(value, "\r\n") {
+		return true
+	}
+
+	// comment prefixes starting an inline comment, or starting a comment
+	// after a whitespace delimiter
+	for _, prefix := range f.CommentPrefixes {
+		var ok bool
+		switch f.InlineComments {
+		case InlineCommentAnywhere:
+			ok = strings.Contains(value, prefix)
+		case InlineCommentWhitespace:
+			ok = strings.HasPrefix(value, prefix) ||
+				strings.Contains(value, " "+prefix) ||
+				strings.Contains(value, "\t"+prefix)
+		default:
+			ok = strings.HasPrefix(value, prefix) && isDelimiter(f.Delimiters, " ")
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// quote returns value as a quoted value, escaping characters using the
+// parser's escape sequences.
+func quote(value string) string {
+	var buf strings.Builder
+	buf.Grow(len(value) + 2)
+	buf.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				buf.WriteString(`\u`)
+				s := strconv.FormatInt(int64(r), 16)
+				buf.WriteString(strings.Repeat("0", 4-len(s)))
+				buf.WriteString(s)
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// unquote decodes the raw quoted value, returning the decoded value and
+// whether or not raw was a valid quoted value.
+//
+// Trailing whitespace after the closing quote is ignored, as it is included
+// in a quoted value by the parser.
+func unquote(raw string) (string, bool) {
+	raw = strings.TrimRight(raw, " \t")
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return "", false
+	}
+	s := raw[1 : len(raw)-1]
+
+	// fast path
+	if !strings.ContainsAny(s, `"\`) {
+		return s, true
+	}
+
+	var buf strings.Builder
+	buf.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			// unescaped quote before the end
+			return "", false
+		case c != '\\':
+			buf.WriteByte(c)
+			i++
+			continue
+		}
+
+		// escape sequence
+		if i+1 >= len(s) {
+			return "", false
+		}
+		switch s[i+1] {
+		case '"', '\\', '/':
+			buf.WriteByte(s[i+1])
+		case 'b':
+			buf.WriteByte('\b')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'u':
+			r, ok := unhex(s[i+2:])
+			if !ok {
+				return "", false
+			}
+			i += 4
+
+			// surrogate pair
+			if utf16.IsSurrogate(r) {
+				if r2, ok := unhex(strings.TrimPrefix(s[i+2:], `\u`)); ok && strings.HasPrefix(s[i+2:], `\u`) {
+					if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+						r = dec
+						i += 6
+					}
+				}
+			}
+			buf.WriteRune(r)
+		default:
+			return "", false
+		}
+		i += 2
+	}
+	return buf.String(), true
+}
+
+// unhex decodes the 4 hex digits at the start of s.
+func unhex(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
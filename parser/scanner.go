@@ -0,0 +1,335 @@
+package parser
+
+import (
+	"io"
+	"strings"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+// EventKind values.
+const (
+	// SectionEvent is a section header.
+	SectionEvent EventKind = iota + 1
+
+	// KeyEvent is a key, with or without a value, including any continuation
+	// lines.
+	KeyEvent
+
+	// CommentEvent is a comment on a line of its own.
+	CommentEvent
+
+	// BlankEvent is a blank line, or a line containing only whitespace.
+	BlankEvent
+
+	// InvalidEvent is a line that could not be parsed, only produced when
+	// scanning with the Lenient option.
+	InvalidEvent
+)
+
+// String satisfies the fmt.Stringer interface.
+func (k EventKind) String() string {
+	switch k {
+	case SectionEvent:
+		return "section"
+	case KeyEvent:
+		return "key"
+	case CommentEvent:
+		return "comment"
+	case BlankEvent:
+		return "blank"
+	case InvalidEvent:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// Event is a line of ini data read by a Scanner.
+type Event struct {
+	// Kind is the kind of line.
+	Kind EventKind
+
+	// Pos is the position of the section header, key or comment, or of the
+	// start of the line for blank and invalid lines.
+	Pos Position
+
+	// Section is the name of the section the line is in, as returned by
+	// Section.Name. For section events, it is the name of the section.
+	Section string
+
+	// Key is the key name, passed through KeyManipFunc.
+	Key string
+
+	// Value is the key's value, retrieved in the same way as Section.Get.
+	// Key-only entries have an empty value.
+	Value string
+
+	// Flag is true when the key is a key-only entry (ie, has no value).
+	Flag bool
+
+	// Comment is the comment text for comment events, or the inline comment
+	// text following a section header or key.
+	Comment string
+
+	// Text is the raw text of the line, without the line ending. The text
+	// of keys with continuation lines includes the line endings of all but
+	// the last line.
+	Text string
+
+	// Err is the parse error for invalid lines.
+	Err *Error
+}
+
+// scannerBufSize is the initial size of a Scanner's read buffer.
+const scannerBufSize = 64 * 1024
+
+// Scanner reads ini data from an io.Reader as a sequence of events, one for
+// each line (or key with its continuation lines), without building a File.
+//
+// Only as much of the data as is needed to scan the current line is held in
+// memory, making Scanner suitable for extracting a few values from large
+// files.
+type Scanner struct {
+	r   io.Reader
+	cfg *config
+
+	// f provides the section and key funcs for the parse configuration.
+	f *File
+
+	// src is the current window of the data, starting at a line start, and
+	// i is the offset of the next line in src.
+	src string
+	i   int
+	l   *lexer
+
+	// farthest is the offset in src of the farthest failed match on the
+	// scanned lines, which is reported for an error on a following line.
+	farthest int
+
+	// size is the size of the next read.
+	size int
+
+	// line and offset are the line number and offset of the start of src.
+	line, offset int
+
+	// read is the number of bytes read, and over is set when the data
+	// exceeds the size set by the MaxBytes option.
+	read int
+	over bool
+
+	// section is the name of the current section, and counts are the number
+	// of sections and keys scanned.
+	section string
+	counts  counts
+
+	eof   bool
+	event Event
+	err   error
+}
+
+// NewScanner creates a Scanner reading ini data from r, using the parse
+// options.
+func NewScanner(r io.Reader, opts ...Option) *Scanner {
+	cfg := newConfig(opts...)
+	if n := cfg.limits.bytes; n > 0 {
+		r = io.LimitReader(r, int64(n)+1)
+	}
+	return &Scanner{
+		r:    r,
+		cfg:  cfg,
+		f:    newFile(nil, cfg),
+		l:    newLexer("", cfg),
+		size: scannerBufSize,
+		line: 1,
+	}
+}
+
+// Scan advances the Scanner to the next event, which is then available
+// through Event. Returns false when the end of the data is reached or an
+// error occurs.
+//
+// When the data cannot be parsed, Err returns an *Error for the position of
+// the error, wrapping ErrSyntax, ErrInvalidEncoding or a *LimitError.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for {
+		if s.i < len(s.src) {
+			s.l.short, s.l.farthest = false, s.farthest
+			line, end, ok := s.l.scanLine(s.i)
+			if s.scanned(line, ok, end) {
+				s.farthest = s.l.farthest
+				return s.emit(line, end, ok)
+			}
+		} else if s.eof {
+			return false
+		}
+		if err := s.limit(); err != nil {
+			s.err = err
+			return false
+		}
+		if err := s.fill(); err != nil {
+			s.err = err
+			return false
+		}
+	}
+}
+
+// scanned returns whether or not the result of scanning the line at i can
+// not be changed by reading more data.
+func (s *Scanner) scanned(line *Line, ok bool, end int) bool {
+	switch {
+	case s.eof:
+		return true
+	case s.l.short:
+		return false
+	case !ok:
+		// the line the error is on must be complete
+		i, _ := s.l.err()
+		return s.complete(i)
+	}
+	if inv, ok := line.item.(*Invalid); ok && !s.complete(inv.errPos.offset) {
+		return false
+	}
+	if s.cfg.continuation != NoContinuation {
+		// the following line is needed to check for continuation lines
+		return s.complete(end)
+	}
+	return true
+}
+
+// complete returns whether or not the line containing offset i in src ends
+// within src.
+func (s *Scanner) complete(i int) bool {
+	return i < len(s.src) && strings.IndexByte(s.src[i:], '\n') >= 0
+}
+
+// emit sets the Scanner's event for the line ending at end, or the Scanner's
+// error when the line could not be parsed.
+func (s *Scanner) emit(line *Line, end int, ok bool) bool {
+	if !ok {
+		// report invalid encoding on the lines scanned before the error
+		i, err := s.l.err()
+		e := len(s.src)
+		if n := strings.IndexByte(s.src[i:], '\n'); n >= 0 {
+			e = i + n + 1
+		}
+		if n := invalidUTF8(s.src[s.i:e]); n >= 0 && !s.cfg.allowInvalidUTF8 {
+			i, err = s.i+n, ErrInvalidEncoding
+		}
+		s.err = &Error{Pos: s.pos(s.l.pos(i)), Err: err}
+		return false
+	}
+	if n := invalidUTF8(s.src[s.i:end]); n >= 0 && !s.cfg.allowInvalidUTF8 {
+		s.err = &Error{Pos: s.pos(s.l.pos(s.i + n)), Err: ErrInvalidEncoding}
+		return false
+	}
+	if n := s.cfg.longLine(s.src[s.i:end]); n >= 0 {
+		s.err = &Error{Pos: s.pos(s.l.pos(s.i + n)), Err: &LimitError{Limit: LimitLineLength, Max: s.cfg.limits.lineLength}}
+		return false
+	}
+	if err := s.counts.add(s.cfg, line.item); err != nil {
+		s.err = &Error{Pos: s.pos(line.pos), Err: err}
+		return false
+	}
+
+	text := strings.TrimRight(s.src[s.i:end], "\r\n")
+	s.i, s.event = end, Event{
+		Section: s.section,
+		Pos:     s.pos(line.pos),
+		Text:    text,
+	}
+	switch v := line.item.(type) {
+	case nil:
+		s.event.Kind = BlankEvent
+	case *Comment:
+		s.event.Kind = CommentEvent
+		s.event.Pos, s.event.Comment = s.pos(v.pos), v.Text()
+	case *Section:
+		s.section = s.f.SectionNameFunc(v.name)
+		s.event.Kind, s.event.Section = SectionEvent, s.section
+		s.event.Pos = s.pos(v.pos)
+		if v.comment != nil {
+			s.event.Comment = v.comment.Text()
+		}
+	case *KeyValuePair:
+		s.event.Kind = KeyEvent
+		s.event.Pos, s.event.Key = s.pos(v.pos), s.f.KeyManipFunc(v.key)
+		if s.event.Flag = v.value == nil; !s.event.Flag {
+			s.event.Value = s.f.value(v)
+		}
+		if v.comment != nil {
+			s.event.Comment = v.comment.Text()
+		}
+	case *Invalid:
+		err := v.err()
+		err.Pos = s.pos(v.errPos)
+		s.event.Kind, s.event.Err = InvalidEvent, err
+	}
+	return true
+}
+
+// pos returns the Position in the data of the position p in src.
+func (s *Scanner) pos(p position) Position {
+	return Position{
+		Line:   s.line + p.line - 1,
+		Col:    p.col,
+		Offset: s.offset + p.offset,
+	}
+}
+
+// limit returns an error when more data is needed to scan the line at i, but
+// reading more would exceed a limit.
+func (s *Scanner) limit() error {
+	if n := s.cfg.longLine(s.src[s.i:]); n >= 0 {
+		return &Error{Pos: s.pos(s.l.pos(s.i + n)), Err: &LimitError{Limit: LimitLineLength, Max: s.cfg.limits.lineLength}}
+	}
+	if s.over {
+		return &Error{Pos: s.pos(s.l.pos(len(s.src))), Err: &LimitError{Limit: LimitBytes, Max: s.cfg.limits.bytes}}
+	}
+	return nil
+}
+
+// fill discards the scanned lines from src and reads more data.
+func (s *Scanner) fill() error {
+	rest := s.src[s.i:]
+	if len(rest) >= s.size/2 {
+		s.size *= 2
+	}
+	buf := make([]byte, len(rest), len(rest)+s.size)
+	copy(buf, rest)
+	n, err := io.ReadAtLeast(s.r, buf[len(rest):cap(buf)], 1)
+	buf = buf[:len(rest)+n]
+	if s.read += n; s.cfg.limits.bytes > 0 && s.read > s.cfg.limits.bytes {
+		buf, s.over = buf[:len(buf)-(s.read-s.cfg.limits.bytes)], true
+	}
+	switch {
+	case err == io.EOF:
+		s.eof = true
+		// ensure the data ends with '\n'
+		if len(buf) != 0 && buf[len(buf)-1] != '\n' {
+			buf = append(buf, '\n')
+		}
+	case err != nil:
+		return err
+	}
+
+	s.line += strings.Count(s.src[:s.i], "\n")
+	s.offset += s.i
+	s.farthest = max(s.farthest-s.i, 0)
+	s.src, s.i = string(buf), 0
+	s.l = newLexer(s.src, s.cfg)
+	return nil
+}
+
+// Event returns the event read by the last call to Scan.
+func (s *Scanner) Event() Event {
+	return s.event
+}
+
+// Err returns the first error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -1,6 +1,9 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 // Section in a File.
 type Section struct {
@@ -84,74 +87,81 @@ func (s *Section) getInsertLocation(idx int) int {
 // getKey returns the KeyValuePair and its line position, or nil and the
 // position the key should be inserted at.
 func (s *Section) getKey(key string) (*KeyValuePair, int) {
-	// loop over lines and find the key
-	lastSectionName := ""
-	var lastSectionPos position
-	for lastIdx, l := range s.file.lines {
-		switch l.item.(type) {
-		case *Section:
-			if lastSectionName == s.name && lastSectionPos == s.pos {
-				// must be entering a new section; so not found, return
-				return nil, s.getInsertLocation(lastIdx - 1)
-			}
-
-			sect, _ := l.item.(*Section)
-			lastSectionName = sect.name
-			lastSectionPos = sect.pos
-
-		case *KeyValuePair:
-			kvp, _ := l.item.(*KeyValuePair)
-			//fmt.Printf(">>> compare: %s//%s :: %s//%s\n", lastSectionName, s.name, kvp.key, key)
-			if (lastSectionName == s.name && lastSectionPos == s.pos) && s.file.KeyCompFunc(kvp.key, key) {
-				return kvp, lastIdx
-			}
-		}
+	if k := s.key(key); k != nil {
+		return k, s.file.itemLine(k)
 	}
-
-	// if we get here, then must be last section of file
-	return nil, s.getInsertLocation(len(s.file.lines) - 1)
+	return nil, s.insertLocation()
 }
 
 // GetRaw returns the raw (unmanipulated) value for a key.
+//
+// Quoted values are returned as-is, including the quotes and any escape
+// sequences.
 func (s *Section) GetRaw(key string) string {
-	k, _ := s.getKey(key)
-	if k != nil && k.value != nil {
-		return *k.value
+	k := s.key(key)
+	if k != nil {
+		return k.raw()
 	}
 	return ""
 }
 
 // Get returns the value for a key.
 //
-// The value is passed through ValueManipFunc.
+// Quoted values are unquoted and their escape sequences (\", \\, \/, \b, \f,
+// \n, \r, \t, and \uXXXX) are decoded, otherwise the value is passed through
+// ValueManipFunc.
+//
+// A value spanning several lines is joined according to the File's
+// Continuation style before being passed through ValueManipFunc.
 func (s *Section) Get(key string) string {
-	return s.file.ValueManipFunc(s.GetRaw(key))
+	k := s.key(key)
+	return s.file.value(k)
 }
 
 // SetKeyValueRaw sets a key's value to the raw (unmanipulated) value.
 //
 // If key already present, then it's value is overwritten. If key doesn't
 // exist, then it is added to the end of the Section.
+//
+// If the value would not be parsed back as the same value (ie, it contains
+// comment characters, line endings, leading or trailing whitespace, or starts
+// with a quote), then it is quoted and escaped.
+//
+// The key is not set if it is not valid. See SetKeyValueRawErr.
 func (s *Section) SetKeyValueRaw(key, value string) {
-	// get position
-	k, pos := s.getKey(key)
+	_ = s.SetKeyValueRawErr(key, value)
+}
 
-	// key is present, set value
-	if k != nil {
-		k.value = &value
-		return
+// SetKeyValueRawErr sets a key's value to the raw (unmanipulated) value.
+//
+// Returns ErrInvalidKey if the key could not be parsed back as the same key
+// (ie, it is empty, has leading whitespace, or contains comment characters,
+// '=', brackets, or line endings). See SetKeyValueRaw.
+func (s *Section) SetKeyValueRawErr(key, value string) error {
+	if err := s.file.validKey(key); err != nil {
+		return err
 	}
+	if s.file.needsQuote(value) {
+		value = quote(value)
+	}
+	return s.setKeyValue(key, value)
+}
 
-	// key doesn't exist, create it...
-
-	// grab default whitespace and line ending
-	ws := DefaultLeadingKeyWhitespace
+// setKeyValue sets a key's value to value, as-is.
+func (s *Section) setKeyValue(key, value string) error {
+	return s.setKeyValuePtr(key, &value)
+}
 
-	// set no ws if empty section
-	if s.name == "" {
-		ws = ""
+// setKeyValuePtr sets a key's value to value, as-is. A nil value sets the key
+// as a key-only entry.
+func (s *Section) setKeyValuePtr(key string, value *string) error {
+	// key is present, set value
+	if k := s.key(key); k != nil {
+		return s.file.setValue(k, value)
 	}
 
+	// key doesn't exist, create it...
+
 	le := DefaultLineEnding
 	if len(s.file.lines) > 0 {
 		// take line ending from first line if present
@@ -159,30 +169,78 @@ func (s *Section) SetKeyValueRaw(key, value string) {
 	}
 
 	// create the key and line
-	k = NewKeyValuePair(position{}, key, "", &value, nil)
-	line := NewLine(position{}, ws, k, le)
+	k := s.file.newKeyValuePair(key, value)
+	s.insertKeyLines(s.insertLocation(), NewLine(position{}, "", k, le))
 
-	// insert line into s.file.lines
-	if pos < 0 {
-		// must be inserting into empty section where there are no keys present
-		s.file.lines = append([]*Line{line}, s.file.lines...)
-	} else {
-		// copy whitespace from previous line if its a kvp
+	// add key to s.keys
+	s.keys = append(s.keys, k.key)
+	s.file.indexKey(s, k)
+	if value != nil {
+		s.file.unindentNext(k)
+	}
+	return nil
+}
+
+// setValue sets the raw value of the existing key k, replacing any
+// continuation lines. A nil value makes k a key-only entry.
+//
+// Returns ErrInlineCommentsDisabled when k is a key-only entry with an inline
+// comment and the File was parsed with InlineCommentNone, as the comment
+// would be parsed back as part of the value.
+func (f *File) setValue(k *KeyValuePair, value *string) error {
+	if value != nil && k.value == nil && k.comment != nil && f.InlineComments == InlineCommentNone {
+		return fmt.Errorf("%s: %w", k.key, ErrInlineCommentsDisabled)
+	}
+	if value != nil && k.value == nil {
+		k.delim = f.delimiter()
+	}
+	value = f.quoteDelim(k.delim, value)
+	switch {
+	case value == nil && k.value != nil:
+		k.ws = ""
+		f.resetDelimiter()
+	case value != nil && k.value == nil:
+		// separate value from comment
+		if k.ws = ""; k.comment != nil {
+			v := *value + " "
+			value = &v
+		}
+		f.unindentNext(k)
+	}
+	k.value, k.conts = value, nil
+	f.separateComment(k)
+	return nil
+}
+
+// insertKeyLines inserts the lines for a key (and its doc comment) into
+// s.file.lines at pos, as returned by getKey, setting the leading whitespace
+// of the lines to match the Section's other keys.
+func (s *Section) insertKeyLines(pos int, lines ...*Line) {
+	// grab default whitespace
+	ws := s.file.LeadingKeyWhitespace
+
+	// set no ws if empty section
+	if s.name == "" {
+		ws = ""
+	}
+
+	// copy whitespace from previous line if its a kvp
+	if pos > 0 {
 		if _, ok := s.file.lines[pos-1].item.(*KeyValuePair); ok {
-			line.ws = s.file.lines[pos-1].ws
+			ws = s.file.lines[pos-1].ws
 		}
+	}
 
-		s.file.lines = append(
-			s.file.lines[:pos],
-			append(
-				[]*Line{line},
-				s.file.lines[pos:]...,
-			)...,
-		)
+	for _, l := range lines {
+		l.ws = ws
 	}
 
-	// add key to s.keys
-	s.keys = append(s.keys, k.key)
+	// insert lines into s.file.lines
+	if pos < 0 {
+		// must be inserting into empty section where there are no keys present
+		pos = 0
+	}
+	s.file.lines = slices.Insert(s.file.lines, pos, lines...)
 }
 
 // SetKey sets a key to the provided value.
@@ -190,28 +248,158 @@ func (s *Section) SetKeyValueRaw(key, value string) {
 // If key already present, then it's value is overwritten. If key doesn't
 // exist, then it is added to the end of the Section.
 //
-// Passes key through KeyManipFunc and value through ValueManipFunc.
+// Passes key through KeyManipFunc. A value that would not be parsed back as
+// the same value is quoted and escaped as-is, preserving any leading and
+// trailing whitespace, so that Get returns the same value after the File is
+// written and parsed again. Other values are passed through ValueManipFunc.
+//
+// The key is not set if it is not valid. See SetKeyErr.
 func (s *Section) SetKey(key, value string) {
-	s.SetKeyValueRaw(s.file.KeyManipFunc(key), s.file.ValueManipFunc(value))
+	_ = s.SetKeyErr(key, value)
+}
+
+// SetKeyErr sets a key to the provided value, returning ErrInvalidKey if the
+// key is not valid, or ErrInlineCommentsDisabled if the key is a key-only
+// entry with an inline comment in a File parsed with InlineCommentNone.
+//
+// See SetKey.
+func (s *Section) SetKeyErr(key, value string) error {
+	key = s.file.KeyManipFunc(key)
+	if err := s.file.validKey(key); err != nil {
+		return err
+	}
+	if lines, ok := s.file.continuationLines(value); ok {
+		if err := s.setKeyValue(key, lines[0]); err != nil {
+			return err
+		}
+		s.setContinuation(key, lines[1:])
+		return nil
+	}
+	return s.setKeyValue(key, s.file.encodeValue(value))
+}
+
+// HasKey determines if key is defined in Section, either with a value or as
+// a key-only entry.
+func (s *Section) HasKey(key string) bool {
+	k := s.key(key)
+	return k != nil
+}
+
+// HasValue determines if key is defined in Section with a value (ie, it is
+// not a key-only entry). A key with an empty value (ie, "key=") has a value.
+func (s *Section) HasValue(key string) bool {
+	k := s.key(key)
+	return k != nil && k.value != nil
+}
+
+// SetFlag sets key as a key-only entry without a value (ie, a bare flag such
+// as "skip-name-resolve").
+//
+// If key already present, then its value is removed. If key doesn't exist,
+// then it is added to the end of the Section. Passes key through
+// KeyManipFunc. Returns ErrInvalidKey if the key is not valid.
+func (s *Section) SetFlag(key string) error {
+	key = s.file.KeyManipFunc(key)
+	if err := s.file.validKey(key); err != nil {
+		return err
+	}
+	return s.setKeyValuePtr(key, nil)
+}
+
+// mapValue returns the value for key as reported by GetMap, which is
+// FlagValue for key-only entries.
+func (s *Section) mapValue(key string) string {
+	k := s.key(key)
+	if k != nil && k.value == nil {
+		return FlagValue
+	}
+	return s.Get(key)
 }
 
 // RemoveKey removes a key and its value from Section.
 //
-// If there is a comment on the line, it will not be removed.
+// The key's doc comment (the comment lines directly above the key) is also
+// removed.
 func (s *Section) RemoveKey(key string) {
 	k, pos := s.getKey(key)
 	if k != nil {
-		s.file.lines = append(s.file.lines[:pos], s.file.lines[pos+1:]...)
-
-		// find place in s.keys
-		idx := 0
-		for ; idx < len(s.keys); idx++ {
-			if s.file.KeyCompFunc(key, s.keys[idx]) {
-				break
-			}
+		start := s.file.docStart(pos)
+		s.file.lines = append(s.file.lines[:start], s.file.lines[pos+1:]...)
+		s.removeKey(k.key)
+		s.file.unindexKey(s, k)
+	}
+}
+
+// removeKey removes key from s.keys.
+func (s *Section) removeKey(key string) {
+	for idx, k := range s.keys {
+		if s.file.KeyCompFunc(key, k) {
+			s.keys = append(s.keys[:idx], s.keys[idx+1:]...)
+			return
 		}
+	}
+}
 
-		// remove from s.keys
-		s.keys = append(s.keys[:idx], s.keys[idx+1:]...)
+// RenameKey renames a key in Section, preserving its value and comments.
+//
+// Passes name through KeyManipFunc. Returns ErrKeyNotFound if key is not
+// defined, ErrInvalidKey if name is not valid, or ErrKeyExists if name is
+// already defined.
+func (s *Section) RenameKey(key, name string) error {
+	name = s.file.KeyManipFunc(name)
+	if err := s.file.validKey(name); err != nil {
+		return err
+	}
+	k := s.key(key)
+	if k == nil {
+		return fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	if e := s.key(name); e != nil && e != k {
+		return fmt.Errorf("%s: %w", name, ErrKeyExists)
 	}
+
+	for idx, v := range s.keys {
+		if v == k.key {
+			s.keys[idx] = name
+			break
+		}
+	}
+	k.key = name
+	s.file.ResetIndex()
+	return nil
+}
+
+// MoveKey moves a key, along with its value and comments, to the end of the
+// dest Section.
+//
+// Returns ErrKeyNotFound if key is not defined, ErrSectionNotFound if dest is
+// nil or is not a Section of the same File, or ErrKeyExists if key is already
+// defined in dest.
+func (s *Section) MoveKey(key string, dest *Section) error {
+	k, pos := s.getKey(key)
+	if k == nil {
+		return fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	if dest == nil || dest.file != s.file {
+		return fmt.Errorf("%s: %w", key, ErrSectionNotFound)
+	}
+	if dest == s {
+		return nil
+	}
+	if dest.key(k.key) != nil {
+		return fmt.Errorf("%s: %w", key, ErrKeyExists)
+	}
+
+	// remove key and doc comment lines
+	start := s.file.docStart(pos)
+	lines := append([]*Line(nil), s.file.lines[start:pos+1]...)
+	s.file.lines = append(s.file.lines[:start], s.file.lines[pos+1:]...)
+	s.removeKey(k.key)
+	s.file.unindexKey(s, k)
+
+	// add to dest
+	dest.insertKeyLines(dest.insertLocation(), lines...)
+	dest.keys = append(dest.keys, k.key)
+	dest.file.indexKey(dest, k)
+	return nil
 }
@@ -0,0 +1,457 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrKeyNotFound is the error returned by the typed getters when a key is
+// not defined.
+var ErrKeyNotFound = errors.New("key not found")
+
+// ValueError is the error returned by the typed getters when a key's value
+// cannot be retrieved or converted.
+type ValueError struct {
+	Key   string   // key name (in form of section.key when retrieved from a File)
+	Value string   // value that could not be converted
+	Pos   Position // position of the value (zero if not parsed from data)
+	Err   error    // underlying error
+}
+
+// Error satisfies the error interface.
+func (err *ValueError) Error() string {
+	if err.Err == ErrKeyNotFound {
+		return fmt.Sprintf("%s: %v", err.Key, err.Err)
+	}
+	if err.Pos.Line != 0 {
+		return fmt.Sprintf("%s (line %d:%d): invalid value %q: %v", err.Key, err.Pos.Line, err.Pos.Col, err.Value, err.Err)
+	}
+	return fmt.Sprintf("%s: invalid value %q: %v", err.Key, err.Value, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *ValueError) Unwrap() error {
+	return err.Err
+}
+
+// valuePos returns the Position of the key's value, or of the key for
+// key-only entries.
+func (kvp *KeyValuePair) valuePos() Position {
+	if kvp.pos == (position{}) {
+		return Position{}
+	}
+	var s string
+	if kvp.value != nil {
+		s = kvp.key + kvp.delim + kvp.ws
+	}
+	return Position{
+		Line:   kvp.pos.line,
+		Col:    kvp.pos.col + utf8.RuneCountInString(s),
+		Offset: kvp.pos.offset + len(s),
+	}
+}
+
+// convert retrieves the value for key and passes it to conv, wrapping any
+// error in a ValueError.
+func (s *Section) convert(key string, conv func(string) error) error {
+	k := s.key(key)
+	if k == nil {
+		return &ValueError{Key: key, Err: ErrKeyNotFound}
+	}
+	v := s.Get(key)
+	if err := conv(v); err != nil {
+		return &ValueError{Key: key, Value: v, Pos: k.valuePos(), Err: err}
+	}
+	return nil
+}
+
+// GetFunc retrieves the value for a key and passes it to conv.
+//
+// Returns a ValueError if the key is not defined, or wrapping any error
+// returned by conv.
+func (s *Section) GetFunc(key string, conv func(string) error) error {
+	return s.convert(key, conv)
+}
+
+// GetBool returns the value for a key as a bool.
+//
+// Accepts the values accepted by strconv.ParseBool. Key-only entries (ie,
+// bare flags) are reported as true.
+func (s *Section) GetBool(key string) (bool, error) {
+	if s.HasKey(key) && !s.HasValue(key) {
+		return true, nil
+	}
+	var b bool
+	err := s.convert(key, func(v string) (err error) {
+		b, err = strconv.ParseBool(v)
+		return err
+	})
+	return b, err
+}
+
+// GetBoolDefault returns the value for a key as a bool, or def if the key is
+// not defined or its value is invalid.
+func (s *Section) GetBoolDefault(key string, def bool) bool {
+	if b, err := s.GetBool(key); err == nil {
+		return b
+	}
+	return def
+}
+
+// GetInt returns the value for a key as an int.
+//
+// The value is parsed as a base 10 integer.
+func (s *Section) GetInt(key string) (int, error) {
+	var i int64
+	err := s.convert(key, func(v string) (err error) {
+		i, err = strconv.ParseInt(v, 10, strconv.IntSize)
+		return err
+	})
+	return int(i), err
+}
+
+// GetIntDefault returns the value for a key as an int, or def if the key is
+// not defined or its value is invalid.
+func (s *Section) GetIntDefault(key string, def int) int {
+	if i, err := s.GetInt(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// GetInt64 returns the value for a key as an int64.
+//
+// The value is parsed as a base 10 integer.
+func (s *Section) GetInt64(key string) (int64, error) {
+	var i int64
+	err := s.convert(key, func(v string) (err error) {
+		i, err = strconv.ParseInt(v, 10, 64)
+		return err
+	})
+	return i, err
+}
+
+// GetInt64Default returns the value for a key as an int64, or def if the key
+// is not defined or its value is invalid.
+func (s *Section) GetInt64Default(key string, def int64) int64 {
+	if i, err := s.GetInt64(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// GetUint returns the value for a key as a uint.
+//
+// The value is parsed as a base 10 integer.
+func (s *Section) GetUint(key string) (uint, error) {
+	var u uint64
+	err := s.convert(key, func(v string) (err error) {
+		u, err = strconv.ParseUint(v, 10, strconv.IntSize)
+		return err
+	})
+	return uint(u), err
+}
+
+// GetUintDefault returns the value for a key as a uint, or def if the key is
+// not defined or its value is invalid.
+func (s *Section) GetUintDefault(key string, def uint) uint {
+	if u, err := s.GetUint(key); err == nil {
+		return u
+	}
+	return def
+}
+
+// GetFloat returns the value for a key as a float64.
+func (s *Section) GetFloat(key string) (float64, error) {
+	var f float64
+	err := s.convert(key, func(v string) (err error) {
+		f, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	return f, err
+}
+
+// GetFloatDefault returns the value for a key as a float64, or def if the key
+// is not defined or its value is invalid.
+func (s *Section) GetFloatDefault(key string, def float64) float64 {
+	if f, err := s.GetFloat(key); err == nil {
+		return f
+	}
+	return def
+}
+
+// GetDuration returns the value for a key as a time.Duration.
+//
+// Accepts the values accepted by time.ParseDuration.
+func (s *Section) GetDuration(key string) (time.Duration, error) {
+	var d time.Duration
+	err := s.convert(key, func(v string) (err error) {
+		d, err = time.ParseDuration(v)
+		return err
+	})
+	return d, err
+}
+
+// GetDurationDefault returns the value for a key as a time.Duration, or def
+// if the key is not defined or its value is invalid.
+func (s *Section) GetDurationDefault(key string, def time.Duration) time.Duration {
+	if d, err := s.GetDuration(key); err == nil {
+		return d
+	}
+	return def
+}
+
+// GetTime returns the value for a key as a time.Time, parsed using layout.
+//
+// See time.Parse for information on layout.
+func (s *Section) GetTime(key, layout string) (time.Time, error) {
+	var t time.Time
+	err := s.convert(key, func(v string) (err error) {
+		t, err = time.Parse(layout, v)
+		return err
+	})
+	return t, err
+}
+
+// GetTimeDefault returns the value for a key as a time.Time, parsed using
+// layout, or def if the key is not defined or its value is invalid.
+func (s *Section) GetTimeDefault(key, layout string, def time.Time) time.Time {
+	if t, err := s.GetTime(key, layout); err == nil {
+		return t
+	}
+	return def
+}
+
+// GetStrings returns the value for a key split by sep, with any surrounding
+// whitespace removed from each of the values.
+//
+// An empty value returns a nil slice.
+func (s *Section) GetStrings(key, sep string) ([]string, error) {
+	var vals []string
+	err := s.convert(key, func(v string) error {
+		if v == "" {
+			return nil
+		}
+		vals = strings.Split(v, sep)
+		for i, val := range vals {
+			vals[i] = strings.TrimSpace(val)
+		}
+		return nil
+	})
+	return vals, err
+}
+
+// GetStringsDefault returns the value for a key split by sep, or def if the
+// key is not defined.
+func (s *Section) GetStringsDefault(key, sep string, def []string) []string {
+	if vals, err := s.GetStrings(key, sep); err == nil {
+		return vals
+	}
+	return def
+}
+
+// convert retrieves the Section for the key in form of section.key and passes
+// it to conv, setting the key name of any ValueError to the full key name.
+//
+// Uses File's NameSplitFunc to split the key.
+func (f *File) convert(key string, conv func(*Section, string) error) error {
+	name, k := f.NameSplitFunc(key)
+
+	// get the section
+	section := f.GetSection(name)
+	if section == nil {
+		return &ValueError{Key: key, Err: ErrKeyNotFound}
+	}
+
+	err := conv(section, k)
+	if e, ok := err.(*ValueError); ok {
+		e.Key = key
+	}
+	return err
+}
+
+// GetFunc retrieves the value for a key in form of section.key and passes it
+// to conv.
+//
+// See Section.GetFunc.
+func (f *File) GetFunc(key string, conv func(string) error) error {
+	return f.convert(key, func(s *Section, k string) error {
+		return s.convert(k, conv)
+	})
+}
+
+// GetBool returns the value for a key in form of section.key as a bool.
+//
+// See Section.GetBool.
+func (f *File) GetBool(key string) (bool, error) {
+	var b bool
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		b, err = s.GetBool(k)
+		return err
+	})
+	return b, err
+}
+
+// GetBoolDefault returns the value for a key in form of section.key as a
+// bool, or def if the key is not defined or its value is invalid.
+func (f *File) GetBoolDefault(key string, def bool) bool {
+	if b, err := f.GetBool(key); err == nil {
+		return b
+	}
+	return def
+}
+
+// GetInt returns the value for a key in form of section.key as an int.
+//
+// See Section.GetInt.
+func (f *File) GetInt(key string) (int, error) {
+	var i int
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		i, err = s.GetInt(k)
+		return err
+	})
+	return i, err
+}
+
+// GetIntDefault returns the value for a key in form of section.key as an
+// int, or def if the key is not defined or its value is invalid.
+func (f *File) GetIntDefault(key string, def int) int {
+	if i, err := f.GetInt(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// GetInt64 returns the value for a key in form of section.key as an int64.
+//
+// See Section.GetInt64.
+func (f *File) GetInt64(key string) (int64, error) {
+	var i int64
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		i, err = s.GetInt64(k)
+		return err
+	})
+	return i, err
+}
+
+// GetInt64Default returns the value for a key in form of section.key as an
+// int64, or def if the key is not defined or its value is invalid.
+func (f *File) GetInt64Default(key string, def int64) int64 {
+	if i, err := f.GetInt64(key); err == nil {
+		return i
+	}
+	return def
+}
+
+// GetUint returns the value for a key in form of section.key as a uint.
+//
+// See Section.GetUint.
+func (f *File) GetUint(key string) (uint, error) {
+	var u uint
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		u, err = s.GetUint(k)
+		return err
+	})
+	return u, err
+}
+
+// GetUintDefault returns the value for a key in form of section.key as a
+// uint, or def if the key is not defined or its value is invalid.
+func (f *File) GetUintDefault(key string, def uint) uint {
+	if u, err := f.GetUint(key); err == nil {
+		return u
+	}
+	return def
+}
+
+// GetFloat returns the value for a key in form of section.key as a float64.
+//
+// See Section.GetFloat.
+func (f *File) GetFloat(key string) (float64, error) {
+	var n float64
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		n, err = s.GetFloat(k)
+		return err
+	})
+	return n, err
+}
+
+// GetFloatDefault returns the value for a key in form of section.key as a
+// float64, or def if the key is not defined or its value is invalid.
+func (f *File) GetFloatDefault(key string, def float64) float64 {
+	if n, err := f.GetFloat(key); err == nil {
+		return n
+	}
+	return def
+}
+
+// GetDuration returns the value for a key in form of section.key as a
+// time.Duration.
+//
+// See Section.GetDuration.
+func (f *File) GetDuration(key string) (time.Duration, error) {
+	var d time.Duration
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		d, err = s.GetDuration(k)
+		return err
+	})
+	return d, err
+}
+
+// GetDurationDefault returns the value for a key in form of section.key as a
+// time.Duration, or def if the key is not defined or its value is invalid.
+func (f *File) GetDurationDefault(key string, def time.Duration) time.Duration {
+	if d, err := f.GetDuration(key); err == nil {
+		return d
+	}
+	return def
+}
+
+// GetTime returns the value for a key in form of section.key as a
+// time.Time, parsed using layout.
+//
+// See Section.GetTime.
+func (f *File) GetTime(key, layout string) (time.Time, error) {
+	var t time.Time
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		t, err = s.GetTime(k, layout)
+		return err
+	})
+	return t, err
+}
+
+// GetTimeDefault returns the value for a key in form of section.key as a
+// time.Time, parsed using layout, or def if the key is not defined or its
+// value is invalid.
+func (f *File) GetTimeDefault(key, layout string, def time.Time) time.Time {
+	if t, err := f.GetTime(key, layout); err == nil {
+		return t
+	}
+	return def
+}
+
+// GetStrings returns the value for a key in form of section.key split by
+// sep.
+//
+// See Section.GetStrings.
+func (f *File) GetStrings(key, sep string) ([]string, error) {
+	var vals []string
+	err := f.convert(key, func(s *Section, k string) (err error) {
+		vals, err = s.GetStrings(k, sep)
+		return err
+	})
+	return vals, err
+}
+
+// GetStringsDefault returns the value for a key in form of section.key split
+// by sep, or def if the key is not defined.
+func (f *File) GetStringsDefault(key, sep string, def []string) []string {
+	if vals, err := f.GetStrings(key, sep); err == nil {
+		return vals
+	}
+	return def
+}
@@ -0,0 +1,101 @@
+package ini
+
+import (
+	"io"
+)
+
+// Decoder reads and decodes ini data from an input stream.
+type Decoder struct {
+	r       io.Reader
+	dialect Dialect
+	strict  bool
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: r,
+	}
+}
+
+// SetDialect sets the Dialect used to configure the decoded File.
+func (d *Decoder) SetDialect(dialect Dialect) {
+	d.dialect = dialect
+}
+
+// DisallowUnknownKeys causes Decode to return an error when the ini data
+// contains a key that is not mapped to a field in the destination struct.
+func (d *Decoder) DisallowUnknownKeys() {
+	d.strict = true
+}
+
+// Decode reads the ini data from the input stream and stores it in the value
+// pointed to by v.
+//
+// If v is a *File, then it is set to the parsed File. Otherwise, v must be a
+// pointer to a struct. See File.Decode for information on how sections and
+// keys are mapped to struct fields.
+func (d *Decoder) Decode(v interface{}) error {
+	f, err := Load(d.r)
+	if err != nil {
+		return err
+	}
+	if d.dialect != nil {
+		d.dialect(f)
+	}
+
+	if file, ok := v.(*File); ok {
+		*file = *f
+		return nil
+	}
+	return f.decode(v, d.strict)
+}
+
+// Encoder encodes and writes ini data to an output stream.
+type Encoder struct {
+	w       io.Writer
+	dialect Dialect
+	indent  *string
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// SetDialect sets the Dialect used to configure the encoded File.
+func (e *Encoder) SetDialect(dialect Dialect) {
+	e.dialect = dialect
+}
+
+// SetIndent sets the leading whitespace used for keys in non-empty sections.
+//
+// Defaults to parser.DefaultLeadingKeyWhitespace.
+func (e *Encoder) SetIndent(indent string) {
+	e.indent = &indent
+}
+
+// Encode writes the ini encoding of v to the output stream.
+//
+// If v is a *File, then its data is written as-is. Otherwise, v must be a
+// struct or a pointer to a struct. See File.Encode for information on how
+// struct fields are mapped to sections and keys.
+func (e *Encoder) Encode(v interface{}) error {
+	f, ok := v.(*File)
+	if !ok {
+		f = NewFile()
+		if e.dialect != nil {
+			e.dialect(f)
+		}
+		if e.indent != nil {
+			f.LeadingKeyWhitespace = *e.indent
+		}
+		if err := f.Encode(v); err != nil {
+			return err
+		}
+	}
+	_, err := f.WriteTo(e.w)
+	return err
+}
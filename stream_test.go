@@ -0,0 +1,107 @@
+package ini
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	data := "name = test\n[remote \"origin\"]\nurl = git@example.com:repo.git\n"
+
+	var cfg struct {
+		Name   string `ini:"name"`
+		Remote struct {
+			Origin struct {
+				URL string `ini:"url"`
+			} `ini:"origin"`
+		} `ini:"remote"`
+	}
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.SetDialect(GitDialect)
+	if err := dec.Decode(&cfg); err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	if cfg.Name != "test" {
+		t.Errorf("name should be test, got: %q", cfg.Name)
+	}
+	if cfg.Remote.Origin.URL != "git@example.com:repo.git" {
+		t.Errorf("remote.origin.url should be git@example.com:repo.git, got: %q", cfg.Remote.Origin.URL)
+	}
+
+	// decode into file
+	var f File
+	if err := NewDecoder(strings.NewReader(data)).Decode(&f); err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	if data != f.String() {
+		t.Errorf("decoded file should be same as original, got: %q", f.String())
+	}
+}
+
+func TestDecoderDisallowUnknownKeys(t *testing.T) {
+	data := "name = test\n[section]\nkey = value\n"
+
+	var cfg struct {
+		Name string `ini:"name"`
+	}
+
+	if err := NewDecoder(strings.NewReader(data)).Decode(&cfg); err != nil {
+		t.Fatalf("unknown keys should be ignored by default, got: %v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownKeys()
+	err := dec.Decode(&cfg)
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got: %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "section.key:") {
+		t.Errorf("error should reference section.key, got: %v", err)
+	}
+
+	// repeated keys spelled with different case are all used
+	dec = NewDecoder(strings.NewReader("Name = a\nname = test\n"))
+	dec.DisallowUnknownKeys()
+	if err := dec.Decode(&cfg); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var cfg struct {
+		Name    string `ini:"name"`
+		Section struct {
+			Key string `ini:"key"`
+		} `ini:"section"`
+	}
+	cfg.Name = "test"
+	cfg.Section.Key = "value"
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("  ")
+	if err := enc.Encode(cfg); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+
+	d0 := "name=test\n[section]\n  key=value\n"
+	if d0 != buf.String() {
+		t.Errorf("expected %q, got: %q", d0, buf.String())
+	}
+
+	// encode file
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	buf.Reset()
+	if err := NewEncoder(&buf).Encode(f); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+	if d0 != buf.String() {
+		t.Errorf("expected %q, got: %q", d0, buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package ini
+
+import (
+	"reflect"
+
+	"github.com/kenshaw/ini/parser"
+)
+
+// Get retrieves the value for a key in form of section.key from File,
+// converted to type T.
+//
+// Values are converted to strings, bools, ints, uints, floats,
+// time.Duration, *url.URL, net.IP and other types implementing
+// encoding.TextUnmarshaler, and slices of those (separated by commas).
+//
+// Key-only entries (ie, bare flags) are converted from parser.FlagValue, as
+// when decoding.
+//
+// Returns a *parser.ValueError if the key is not defined or its value could
+// not be converted.
+func Get[T any](f *File, key string) (T, error) {
+	name, k := f.NameSplitFunc(key)
+	s := f.GetSection(name)
+	flag := s != nil && s.HasKey(k) && !s.HasValue(k)
+
+	var v T
+	err := f.GetFunc(key, func(val string) error {
+		if flag {
+			val = parser.FlagValue
+		}
+		return unmarshalValue(reflect.ValueOf(&v).Elem(), val)
+	})
+	if e, ok := err.(*parser.ValueError); ok && flag {
+		e.Value = parser.FlagValue
+	}
+	return v, err
+}
+
+// Set sets the value for a key in form of section.key in File, converted
+// from type T.
+//
+// Values are converted from the same types supported by Get, and types
+// implementing encoding.TextMarshaler. See File.SetKeyErr.
+func Set[T any](f *File, key string, v T) error {
+	s, err := marshalValue(reflect.ValueOf(&v).Elem())
+	if err != nil {
+		return err
+	}
+	return f.SetKeyErr(key, s)
+}
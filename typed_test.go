@@ -0,0 +1,98 @@
+package ini
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/ini/parser"
+)
+
+func TestGetSet(t *testing.T) {
+	d0 := "[server]\nhost = 10.0.0.1\nport = 8080\ntimeout = 5s\nendpoint = https://example.com/api\nports = 80, 443\nlevel = info\n"
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	if ip, err := Get[net.IP](f, "server.host"); err != nil || !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("server.host should be 10.0.0.1, got: %v (%v)", ip, err)
+	}
+	if port, err := Get[uint16](f, "server.port"); err != nil || port != 8080 {
+		t.Errorf("server.port should be 8080, got: %d (%v)", port, err)
+	}
+	if d, err := Get[time.Duration](f, "server.timeout"); err != nil || d != 5*time.Second {
+		t.Errorf("server.timeout should be 5s, got: %v (%v)", d, err)
+	}
+	if u, err := Get[*url.URL](f, "server.endpoint"); err != nil || u.Host != "example.com" || u.Path != "/api" {
+		t.Errorf("server.endpoint should be https://example.com/api, got: %v (%v)", u, err)
+	}
+	if ports, err := Get[[]int](f, "server.ports"); err != nil || !reflect.DeepEqual(ports, []int{80, 443}) {
+		t.Errorf("server.ports should be [80 443], got: %v (%v)", ports, err)
+	}
+	if lvl, err := Get[testLevel](f, "server.level"); err != nil || lvl != testLevel(1) {
+		t.Errorf("server.level should be info, got: %v (%v)", lvl, err)
+	}
+
+	// check errors
+	var ve *parser.ValueError
+	if _, err := Get[int8](f, "server.port"); !errors.As(err, &ve) || ve.Key != "server.port" || ve.Pos.Line != 3 {
+		t.Errorf("server.port should overflow int8 with error on line 3, got: %v", err)
+	}
+	if _, err := Get[string](f, "server.missing"); !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("server.missing should return ErrKeyNotFound, got: %v", err)
+	}
+
+	// key-only entries
+	f2, err := LoadString("[mysqld]\nskip-name-resolve\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+	if b, err := Get[bool](f2, "mysqld.skip-name-resolve"); err != nil || !b {
+		t.Errorf("mysqld.skip-name-resolve should be true, got: %t (%v)", b, err)
+	}
+	if _, err := Get[int](f2, "mysqld.skip-name-resolve"); !errors.As(err, &ve) || ve.Value != parser.FlagValue || ve.Pos.Line != 2 || ve.Pos.Col != 1 {
+		t.Errorf("mysqld.skip-name-resolve should not convert to int with error at 2:1, got: %v", err)
+	}
+
+	// set values
+	u, _ := url.Parse("http://localhost:8080/")
+	if err := Set(f, "server.endpoint", u); err != nil {
+		t.Fatalf("could not set server.endpoint: %v", err)
+	}
+	if err := Set(f, "server.ports", []int{8080, 8443}); err != nil {
+		t.Fatalf("could not set server.ports: %v", err)
+	}
+	if err := Set(f, "server.timeout", time.Minute); err != nil {
+		t.Fatalf("could not set server.timeout: %v", err)
+	}
+	if err := Set(f, "server.host", net.IPv4(127, 0, 0, 1)); err != nil {
+		t.Fatalf("could not set server.host: %v", err)
+	}
+	d1 := "[server]\nhost = 127.0.0.1\nport = 8080\ntimeout = 1m0s\nendpoint = http://localhost:8080/\nports = 8080,8443\nlevel = info\n"
+	if d1 != f.String() {
+		t.Errorf("expected %q, got: %q", d1, f.String())
+	}
+
+	if err := Set(f, "server.ch", make(chan int)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("setting chan should return ErrUnsupportedType, got: %v", err)
+	}
+}
+
+// testLevel is a type implementing encoding.TextUnmarshaler.
+type testLevel int
+
+func (l *testLevel) UnmarshalText(buf []byte) error {
+	switch string(buf) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
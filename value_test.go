@@ -0,0 +1,89 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kenshaw/ini/parser"
+)
+
+func TestTypedGetters(t *testing.T) {
+	d0 := "debug = true\n[server]\nport = 08080\nworkers = -4\nratio = 0.75\ntimeout = 1m30s\nstarted = 2020-01-02\ntags = a, b ,c\nbad = notanumber\n"
+	f, err := LoadString(d0)
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	if b, err := f.GetBool("debug"); err != nil || !b {
+		t.Errorf("debug should be true, got: %t (%v)", b, err)
+	}
+	if i, err := f.GetInt("server.port"); err != nil || i != 8080 {
+		t.Errorf("server.port should be 8080, got: %d (%v)", i, err)
+	}
+	if i, err := f.GetInt64("server.workers"); err != nil || i != -4 {
+		t.Errorf("server.workers should be -4, got: %d (%v)", i, err)
+	}
+	if u, err := f.GetUint("server.port"); err != nil || u != 8080 {
+		t.Errorf("server.port should be 8080, got: %d (%v)", u, err)
+	}
+	if n, err := f.GetFloat("server.ratio"); err != nil || n != 0.75 {
+		t.Errorf("server.ratio should be 0.75, got: %f (%v)", n, err)
+	}
+	if d, err := f.GetDuration("server.timeout"); err != nil || d != 90*time.Second {
+		t.Errorf("server.timeout should be 1m30s, got: %v (%v)", d, err)
+	}
+	exp := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	if tm, err := f.GetTime("server.started", "2006-01-02"); err != nil || !tm.Equal(exp) {
+		t.Errorf("server.started should be %v, got: %v (%v)", exp, tm, err)
+	}
+
+	s := f.GetSection("server")
+	if vals, err := s.GetStrings("tags", ","); err != nil || !reflect.DeepEqual(vals, []string{"a", "b", "c"}) {
+		t.Errorf("tags should be [a b c], got: %v (%v)", vals, err)
+	}
+	if vals, err := s.GetStrings("tags", " "); err != nil || len(vals) != 3 {
+		t.Errorf("tags split on space should have 3 values, got: %q (%v)", vals, err)
+	}
+
+	// check defaults
+	if u := f.GetUintDefault("server.workers", 8); u != 8 {
+		t.Errorf("invalid server.workers should default to 8, got: %d", u)
+	}
+	if i := s.GetIntDefault("nonexistent", 5); i != 5 {
+		t.Errorf("nonexistent should default to 5, got: %d", i)
+	}
+	if b := f.GetBoolDefault("nonexistent.key", true); !b {
+		t.Error("nonexistent.key should default to true")
+	}
+}
+
+func TestTypedGetterErrors(t *testing.T) {
+	f, err := LoadString("[server]\n  port = eighty\n")
+	if err != nil {
+		t.Fatalf("could not load string: %v", err)
+	}
+
+	_, err = f.GetInt("server.port")
+	var ve *parser.ValueError
+	if !errors.As(err, &ve) {
+		t.Fatalf("error should be *parser.ValueError, got: %T", err)
+	}
+	if ve.Key != "server.port" || ve.Value != "eighty" {
+		t.Errorf("error should be for server.port=eighty, got: %s=%s", ve.Key, ve.Value)
+	}
+	if ve.Pos.Line != 2 || ve.Pos.Col != 10 || ve.Pos.Offset != 18 {
+		t.Errorf("error position should be 2:10 (offset 18), got: %d:%d (offset %d)", ve.Pos.Line, ve.Pos.Col, ve.Pos.Offset)
+	}
+
+	_, err = f.GetSection("server").GetBool("missing")
+	if !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("error should be ErrKeyNotFound, got: %v", err)
+	}
+
+	_, err = f.GetFloat("missing.key")
+	if !errors.As(err, &ve) || ve.Key != "missing.key" || !errors.Is(err, parser.ErrKeyNotFound) {
+		t.Errorf("error should be ErrKeyNotFound for missing.key, got: %v", err)
+	}
+}